@@ -7,17 +7,45 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/BurntSushi/toml"
+	_ "github.com/go-sql-driver/mysql" // database/sql driver for the mysql plugin
+	_ "github.com/lib/pq"              // database/sql driver for the postgres plugin
 
 	"github.com/olivere/metronome"
 	"github.com/olivere/metronome/plugins"
+	"github.com/olivere/metronome/plugins/apache"
+	"github.com/olivere/metronome/plugins/cgroup"
+	"github.com/olivere/metronome/plugins/cpu"
+	"github.com/olivere/metronome/plugins/disk"
+	"github.com/olivere/metronome/plugins/diskio"
 	"github.com/olivere/metronome/plugins/elasticsearch"
+	"github.com/olivere/metronome/plugins/exec"
+	"github.com/olivere/metronome/plugins/external"
+	"github.com/olivere/metronome/plugins/haproxy"
+	"github.com/olivere/metronome/plugins/host"
+	"github.com/olivere/metronome/plugins/httpjson"
 	"github.com/olivere/metronome/plugins/loadavg"
 	"github.com/olivere/metronome/plugins/mem"
+	"github.com/olivere/metronome/plugins/memcached"
+	"github.com/olivere/metronome/plugins/mysql"
+	"github.com/olivere/metronome/plugins/net"
+	"github.com/olivere/metronome/plugins/nginx"
+	"github.com/olivere/metronome/plugins/postgres"
+	"github.com/olivere/metronome/plugins/procs"
+	"github.com/olivere/metronome/plugins/promscrape"
+	"github.com/olivere/metronome/plugins/psi"
+	"github.com/olivere/metronome/plugins/redis"
+	"github.com/olivere/metronome/plugins/sensors"
+	"github.com/olivere/metronome/plugins/sockets"
 	"github.com/olivere/metronome/plugins/swap"
+	"github.com/olivere/metronome/plugins/vmstat"
 )
 
 var (
@@ -26,6 +54,9 @@ var (
 	password = flag.String("password", "", "Password for authentication")
 	logfile  = flag.String("log", "", "Log file")
 	conffile = flag.String("c", "metronomed.toml", "Configuration file")
+	procRoot = flag.String("procfs", "", "Mount point of the proc filesystem (default: /proc)")
+	sysRoot  = flag.String("sysfs", "", "Mount point of the sys filesystem (default: /sys)")
+	hostRoot = flag.String("hostfs", "", "Mount point of the root filesystem of the host (default: /)")
 )
 
 func main() {
@@ -57,18 +88,192 @@ func main() {
 
 	go srv.Start()
 
-	select {}
+	// Shut down plugins that run subprocesses on exit
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	<-sigs
+	for _, plugin := range plugins.Plugins() {
+		if c, ok := plugin.(io.Closer); ok {
+			c.Close()
+		}
+	}
 }
 
 type configuration struct {
+	ProcRoot      string `toml:"proc_root"`
+	SysRoot       string `toml:"sys_root"`
+	HostRoot      string `toml:"host_root"`
+	Host          interface{}
 	LoadAvg       interface{} `toml:"loadavg"`
 	Mem           interface{}
 	Swap          interface{}
+	VMStat        interface{} `toml:"vmstat"`
+	CPU           interface{}
+	Disk          *diskconf
+	DiskIO        *diskioconf `toml:"diskio"`
+	Net           *netconf
+	PSI           *psiconf `toml:"psi"`
+	Procs         *procsconf
+	Cgroup        *cgroupconf
+	Sockets       *socketsconf
+	Sensors       interface{}
 	Elasticsearch map[string]*esconf
+	Redis         map[string]*redisconf
+	Memcached     map[string]*memcachedconf
+	Postgres      map[string]*sqlconf
+	MySQL         map[string]*sqlconf `toml:"mysql"`
+	Nginx         *nginxconf
+	Apache        *apacheconf
+	HAProxy       *haproxyconf `toml:"haproxy"`
+	Promscrape    map[string]*promscrapeconf
+	HTTPJSON      map[string]*httpjsonconf `toml:"httpjson"`
+	Exec          map[string]*execconf
+	External      map[string]*externalconf
+}
+
+type diskconf struct {
+	IncludeMountPoints []string `toml:"include_mount_points"`
+	ExcludeMountPoints []string `toml:"exclude_mount_points"`
+	IncludeFSTypes     []string `toml:"include_fstypes"`
+	ExcludeFSTypes     []string `toml:"exclude_fstypes"`
+	Timeout            duration `toml:"timeout"`
+}
+
+type diskioconf struct {
+	IncludeDevices []string `toml:"include_devices"`
+	ExcludeDevices []string `toml:"exclude_devices"`
+}
+
+type netconf struct {
+	IncludeInterfaces []string `toml:"include_interfaces"`
+	ExcludeInterfaces []string `toml:"exclude_interfaces"`
+}
+
+type psiconf struct {
+	Cgroups []string
+}
+
+type procsconf struct {
+	Watch map[string]*procswatchconf
+}
+
+type procswatchconf struct {
+	Name    string
+	Cmdline string
+	Pidfile string
+}
+
+type cgroupconf struct {
+	Cgroups []string
+}
+
+type socketsconf struct {
+	Ports []int
 }
 
 type esconf struct {
-	Urls []string
+	Urls        []string
+	Username    string
+	Password    string
+	APIKey      string `toml:"api_key"`
+	CAFile      string `toml:"ca_file"`
+	Timeout     duration
+	Sniff       *bool
+	Healthcheck *bool
+	Nodes       []string
+	Indices     []string
+}
+
+type redisconf struct {
+	Address  string
+	Username string
+	Password string
+	Timeout  duration
+}
+
+type memcachedconf struct {
+	Address string
+	Timeout duration
+}
+
+type sqlconf struct {
+	DSN     string `toml:"dsn"`
+	Timeout duration
+}
+
+type nginxconf struct {
+	URLs    []string `toml:"urls"`
+	Timeout duration
+}
+
+type apacheconf struct {
+	URLs    []string `toml:"urls"`
+	Timeout duration
+}
+
+type haproxyconf struct {
+	URL            string `toml:"url"`
+	Username       string
+	Password       string
+	Socket         string
+	Timeout        duration
+	IncludeProxies []string `toml:"include_proxies"`
+	ExcludeProxies []string `toml:"exclude_proxies"`
+}
+
+type promscrapeconf struct {
+	Targets []string
+	Timeout duration
+	Include []string
+	Exclude []string
+	Relabel []relabelconf
+}
+
+type relabelconf struct {
+	SourceLabels []string `toml:"source_labels"`
+	Separator    string
+	Regex        string
+	TargetLabel  string `toml:"target_label"`
+	Replacement  string
+	Action       string
+}
+
+type httpjsonconf struct {
+	URL         string `toml:"url"`
+	Headers     map[string]string
+	Username    string
+	Password    string
+	BearerToken string `toml:"bearer_token"`
+	Timeout     duration
+	Metrics     map[string]string
+}
+
+type execconf struct {
+	Command  string
+	Args     []string
+	Timeout  duration
+	Interval duration
+	Format   string
+}
+
+type externalconf struct {
+	Command    string
+	Args       []string
+	Timeout    duration
+	MinBackoff duration `toml:"min_backoff"`
+	MaxBackoff duration `toml:"max_backoff"`
+}
+
+// duration is a time.Duration that can be specified as a string
+// like "10s" in the configuration file.
+type duration struct {
+	time.Duration
+}
+
+func (d *duration) UnmarshalText(text []byte) error {
+	var err error
+	d.Duration, err = time.ParseDuration(string(text))
+	return err
 }
 
 func registerPlugins(conffile string) error {
@@ -78,6 +283,32 @@ func registerPlugins(conffile string) error {
 		return err
 	}
 
+	// Paths of procfs, sysfs and the host root (flags take precedence)
+	if *procRoot != "" {
+		plugins.ProcRoot = *procRoot
+	} else if config.ProcRoot != "" {
+		plugins.ProcRoot = config.ProcRoot
+	}
+	if *sysRoot != "" {
+		plugins.SysRoot = *sysRoot
+	} else if config.SysRoot != "" {
+		plugins.SysRoot = config.SysRoot
+	}
+	if *hostRoot != "" {
+		plugins.HostRoot = *hostRoot
+	} else if config.HostRoot != "" {
+		plugins.HostRoot = config.HostRoot
+	}
+
+	// Host
+	if config.Host != nil {
+		hostPlugin, err := host.NewPlugin()
+		if err != nil {
+			return fmt.Errorf("error initializing host plugin: %v", err)
+		}
+		plugins.Register(hostPlugin)
+	}
+
 	// LoadAvg
 	if config.LoadAvg != nil {
 		loadavgPlugin, err := loadavg.NewPlugin()
@@ -105,10 +336,137 @@ func registerPlugins(conffile string) error {
 		plugins.Register(swapPlugin)
 	}
 
+	// VMStat
+	if config.VMStat != nil {
+		vmstatPlugin, err := vmstat.NewPlugin()
+		if err != nil {
+			return fmt.Errorf("error initializing vmstat plugin: %v", err)
+		}
+		plugins.Register(vmstatPlugin)
+	}
+
+	// CPU
+	if config.CPU != nil {
+		cpuPlugin, err := cpu.NewPlugin()
+		if err != nil {
+			return fmt.Errorf("error initializing cpu plugin: %v", err)
+		}
+		plugins.Register(cpuPlugin)
+	}
+
+	// Disk
+	if config.Disk != nil {
+		diskConfig := &disk.Config{
+			IncludeMountPoints: config.Disk.IncludeMountPoints,
+			ExcludeMountPoints: config.Disk.ExcludeMountPoints,
+			IncludeFSTypes:     config.Disk.IncludeFSTypes,
+			ExcludeFSTypes:     config.Disk.ExcludeFSTypes,
+			Timeout:            config.Disk.Timeout.Duration,
+		}
+		diskPlugin, err := disk.NewPlugin(diskConfig)
+		if err != nil {
+			return fmt.Errorf("error initializing disk plugin: %v", err)
+		}
+		plugins.Register(diskPlugin)
+	}
+
+	// Disk I/O
+	if config.DiskIO != nil {
+		diskioConfig := &diskio.Config{
+			IncludeDevices: config.DiskIO.IncludeDevices,
+			ExcludeDevices: config.DiskIO.ExcludeDevices,
+		}
+		diskioPlugin, err := diskio.NewPlugin(diskioConfig)
+		if err != nil {
+			return fmt.Errorf("error initializing diskio plugin: %v", err)
+		}
+		plugins.Register(diskioPlugin)
+	}
+
+	// Net
+	if config.Net != nil {
+		netConfig := &net.Config{
+			IncludeInterfaces: config.Net.IncludeInterfaces,
+			ExcludeInterfaces: config.Net.ExcludeInterfaces,
+		}
+		netPlugin, err := net.NewPlugin(netConfig)
+		if err != nil {
+			return fmt.Errorf("error initializing net plugin: %v", err)
+		}
+		plugins.Register(netPlugin)
+	}
+
+	// PSI
+	if config.PSI != nil {
+		psiConfig := &psi.Config{Cgroups: config.PSI.Cgroups}
+		psiPlugin, err := psi.NewPlugin(psiConfig)
+		if err != nil {
+			return fmt.Errorf("error initializing psi plugin: %v", err)
+		}
+		plugins.Register(psiPlugin)
+	}
+
+	// Procs
+	if config.Procs != nil {
+		procsConfig := &procs.Config{Watch: make(map[string]*procs.WatchConfig)}
+		for name, wc := range config.Procs.Watch {
+			procsConfig.Watch[name] = &procs.WatchConfig{
+				Name:    wc.Name,
+				Cmdline: wc.Cmdline,
+				Pidfile: wc.Pidfile,
+			}
+		}
+		procsPlugin, err := procs.NewPlugin(procsConfig)
+		if err != nil {
+			return fmt.Errorf("error initializing procs plugin: %v", err)
+		}
+		plugins.Register(procsPlugin)
+	}
+
+	// Cgroup
+	if config.Cgroup != nil {
+		cgroupConfig := &cgroup.Config{Cgroups: config.Cgroup.Cgroups}
+		cgroupPlugin, err := cgroup.NewPlugin(cgroupConfig)
+		if err != nil {
+			return fmt.Errorf("error initializing cgroup plugin: %v", err)
+		}
+		plugins.Register(cgroupPlugin)
+	}
+
+	// Sockets
+	if config.Sockets != nil {
+		socketsConfig := &sockets.Config{Ports: config.Sockets.Ports}
+		socketsPlugin, err := sockets.NewPlugin(socketsConfig)
+		if err != nil {
+			return fmt.Errorf("error initializing sockets plugin: %v", err)
+		}
+		plugins.Register(socketsPlugin)
+	}
+
+	// Sensors
+	if config.Sensors != nil {
+		sensorsPlugin, err := sensors.NewPlugin()
+		if err != nil {
+			return fmt.Errorf("error initializing sensors plugin: %v", err)
+		}
+		plugins.Register(sensorsPlugin)
+	}
+
 	// Elasticsearch
 	if config.Elasticsearch != nil {
 		for name, escfg := range config.Elasticsearch {
-			esConfig := &elasticsearch.Config{Urls: escfg.Urls}
+			esConfig := &elasticsearch.Config{
+				Urls:        escfg.Urls,
+				Username:    escfg.Username,
+				Password:    escfg.Password,
+				APIKey:      escfg.APIKey,
+				CAFile:      escfg.CAFile,
+				Timeout:     escfg.Timeout.Duration,
+				Sniff:       escfg.Sniff,
+				Healthcheck: escfg.Healthcheck,
+				Nodes:       escfg.Nodes,
+				Indices:     escfg.Indices,
+			}
 			esPlugin, err := elasticsearch.NewPlugin(name, esConfig)
 			if err != nil {
 				return fmt.Errorf("error initializing elasticsearch plugin: %v", err)
@@ -117,5 +475,194 @@ func registerPlugins(conffile string) error {
 		}
 	}
 
+	// Redis
+	if config.Redis != nil {
+		for name, rediscfg := range config.Redis {
+			redisConfig := &redis.Config{
+				Address:  rediscfg.Address,
+				Username: rediscfg.Username,
+				Password: rediscfg.Password,
+				Timeout:  rediscfg.Timeout.Duration,
+			}
+			redisPlugin, err := redis.NewPlugin(name, redisConfig)
+			if err != nil {
+				return fmt.Errorf("error initializing redis plugin: %v", err)
+			}
+			plugins.Register(redisPlugin)
+		}
+	}
+
+	// Memcached
+	if config.Memcached != nil {
+		for name, memcachedcfg := range config.Memcached {
+			memcachedConfig := &memcached.Config{
+				Address: memcachedcfg.Address,
+				Timeout: memcachedcfg.Timeout.Duration,
+			}
+			memcachedPlugin, err := memcached.NewPlugin(name, memcachedConfig)
+			if err != nil {
+				return fmt.Errorf("error initializing memcached plugin: %v", err)
+			}
+			plugins.Register(memcachedPlugin)
+		}
+	}
+
+	// Postgres
+	if config.Postgres != nil {
+		for name, pgcfg := range config.Postgres {
+			pgConfig := &postgres.Config{
+				DSN:     pgcfg.DSN,
+				Timeout: pgcfg.Timeout.Duration,
+			}
+			pgPlugin, err := postgres.NewPlugin(name, pgConfig)
+			if err != nil {
+				return fmt.Errorf("error initializing postgres plugin: %v", err)
+			}
+			plugins.Register(pgPlugin)
+		}
+	}
+
+	// MySQL
+	if config.MySQL != nil {
+		for name, mysqlcfg := range config.MySQL {
+			mysqlConfig := &mysql.Config{
+				DSN:     mysqlcfg.DSN,
+				Timeout: mysqlcfg.Timeout.Duration,
+			}
+			mysqlPlugin, err := mysql.NewPlugin(name, mysqlConfig)
+			if err != nil {
+				return fmt.Errorf("error initializing mysql plugin: %v", err)
+			}
+			plugins.Register(mysqlPlugin)
+		}
+	}
+
+	// Nginx
+	if config.Nginx != nil {
+		nginxConfig := &nginx.Config{
+			URLs:    config.Nginx.URLs,
+			Timeout: config.Nginx.Timeout.Duration,
+		}
+		nginxPlugin, err := nginx.NewPlugin(nginxConfig)
+		if err != nil {
+			return fmt.Errorf("error initializing nginx plugin: %v", err)
+		}
+		plugins.Register(nginxPlugin)
+	}
+
+	// Apache
+	if config.Apache != nil {
+		apacheConfig := &apache.Config{
+			URLs:    config.Apache.URLs,
+			Timeout: config.Apache.Timeout.Duration,
+		}
+		apachePlugin, err := apache.NewPlugin(apacheConfig)
+		if err != nil {
+			return fmt.Errorf("error initializing apache plugin: %v", err)
+		}
+		plugins.Register(apachePlugin)
+	}
+
+	// HAProxy
+	if config.HAProxy != nil {
+		haproxyConfig := &haproxy.Config{
+			URL:            config.HAProxy.URL,
+			Username:       config.HAProxy.Username,
+			Password:       config.HAProxy.Password,
+			Socket:         config.HAProxy.Socket,
+			Timeout:        config.HAProxy.Timeout.Duration,
+			IncludeProxies: config.HAProxy.IncludeProxies,
+			ExcludeProxies: config.HAProxy.ExcludeProxies,
+		}
+		haproxyPlugin, err := haproxy.NewPlugin(haproxyConfig)
+		if err != nil {
+			return fmt.Errorf("error initializing haproxy plugin: %v", err)
+		}
+		plugins.Register(haproxyPlugin)
+	}
+
+	// Promscrape
+	if config.Promscrape != nil {
+		for name, promcfg := range config.Promscrape {
+			promConfig := &promscrape.Config{
+				Targets: promcfg.Targets,
+				Timeout: promcfg.Timeout.Duration,
+				Include: promcfg.Include,
+				Exclude: promcfg.Exclude,
+			}
+			for _, rule := range promcfg.Relabel {
+				promConfig.Relabel = append(promConfig.Relabel, promscrape.RelabelRule{
+					SourceLabels: rule.SourceLabels,
+					Separator:    rule.Separator,
+					Regex:        rule.Regex,
+					TargetLabel:  rule.TargetLabel,
+					Replacement:  rule.Replacement,
+					Action:       rule.Action,
+				})
+			}
+			promPlugin, err := promscrape.NewPlugin(name, promConfig)
+			if err != nil {
+				return fmt.Errorf("error initializing promscrape plugin: %v", err)
+			}
+			plugins.Register(promPlugin)
+		}
+	}
+
+	// HTTPJSON
+	if config.HTTPJSON != nil {
+		for name, httpjsoncfg := range config.HTTPJSON {
+			httpjsonConfig := &httpjson.Config{
+				URL:         httpjsoncfg.URL,
+				Headers:     httpjsoncfg.Headers,
+				Username:    httpjsoncfg.Username,
+				Password:    httpjsoncfg.Password,
+				BearerToken: httpjsoncfg.BearerToken,
+				Timeout:     httpjsoncfg.Timeout.Duration,
+				Metrics:     httpjsoncfg.Metrics,
+			}
+			httpjsonPlugin, err := httpjson.NewPlugin(name, httpjsonConfig)
+			if err != nil {
+				return fmt.Errorf("error initializing httpjson plugin: %v", err)
+			}
+			plugins.Register(httpjsonPlugin)
+		}
+	}
+
+	// Exec
+	if config.Exec != nil {
+		for name, execcfg := range config.Exec {
+			execConfig := &exec.Config{
+				Command:  execcfg.Command,
+				Args:     execcfg.Args,
+				Timeout:  execcfg.Timeout.Duration,
+				Interval: execcfg.Interval.Duration,
+				Format:   execcfg.Format,
+			}
+			execPlugin, err := exec.NewPlugin(name, execConfig)
+			if err != nil {
+				return fmt.Errorf("error initializing exec plugin: %v", err)
+			}
+			plugins.Register(execPlugin)
+		}
+	}
+
+	// External
+	if config.External != nil {
+		for name, extcfg := range config.External {
+			extConfig := &external.Config{
+				Command:    extcfg.Command,
+				Args:       extcfg.Args,
+				Timeout:    extcfg.Timeout.Duration,
+				MinBackoff: extcfg.MinBackoff.Duration,
+				MaxBackoff: extcfg.MaxBackoff.Duration,
+			}
+			extPlugin, err := external.NewPlugin(name, extConfig)
+			if err != nil {
+				return fmt.Errorf("error initializing external plugin: %v", err)
+			}
+			plugins.Register(extPlugin)
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,119 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package apache
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Scoreboard states of the workers, by the character used in the
+// scoreboard of mod_status.
+var scoreboardStates = map[rune]string{
+	'_': "waiting",
+	'S': "starting",
+	'R': "reading",
+	'W': "sending",
+	'K': "keepalive",
+	'D': "dns_lookup",
+	'C': "closing",
+	'L': "logging",
+	'G': "finishing",
+	'I': "idle_cleanup",
+	'.': "open_slot",
+}
+
+// ServerStatus is the machine-readable output of mod_status.
+type ServerStatus struct {
+	TotalAccesses int64 // requests, cumulative
+	TotalKBytes   int64 // traffic in kB, cumulative
+	Uptime        int64 // seconds
+	BusyWorkers   int64
+	IdleWorkers   int64
+	ConnsTotal    int64 // connections, only with the event MPM
+
+	// Scoreboard is the number of workers by state, e.g. "sending".
+	Scoreboard map[string]int64
+}
+
+// GetServerStatus fetches and parses the server-status page at url.
+// The url must request the machine-readable format, i.e. end in
+// "?auto".
+func GetServerStatus(client *http.Client, url string) (*ServerStatus, error) {
+	res, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", res.Status)
+	}
+	return ParseServerStatus(res.Body)
+}
+
+// ParseServerStatus parses the output of server-status?auto, which
+// consists of lines like:
+//
+//	Total Accesses: 131
+//	BusyWorkers: 1
+//	Scoreboard: _W___...
+func ParseServerStatus(r io.Reader) (*ServerStatus, error) {
+	s := &ServerStatus{Scoreboard: make(map[string]int64)}
+	for _, state := range scoreboardStates {
+		s.Scoreboard[state] = 0
+	}
+	found := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		i := strings.Index(line, ":")
+		if i < 0 {
+			continue
+		}
+		key, value := line[:i], strings.TrimSpace(line[i+1:])
+		var v *int64
+		switch key {
+		case "Total Accesses":
+			v = &s.TotalAccesses
+		case "Total kBytes":
+			v = &s.TotalKBytes
+		case "Uptime":
+			v = &s.Uptime
+		case "BusyWorkers":
+			v = &s.BusyWorkers
+		case "IdleWorkers":
+			v = &s.IdleWorkers
+		case "ConnsTotal":
+			v = &s.ConnsTotal
+		case "Scoreboard":
+			for _, c := range value {
+				if state, ok := scoreboardStates[c]; ok {
+					s.Scoreboard[state]++
+				}
+			}
+			found = true
+			continue
+		default:
+			continue
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %q", key, value)
+		}
+		*v = n
+		found = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("invalid server-status format; does the URL end in ?auto")
+	}
+	return s, nil
+}
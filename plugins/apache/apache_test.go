@@ -0,0 +1,68 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package apache
+
+import (
+	"strings"
+	"testing"
+)
+
+const serverStatus = `localhost
+ServerVersion: Apache/2.4.41 (Ubuntu)
+ServerMPM: event
+Total Accesses: 131
+Total kBytes: 256
+Uptime: 3600
+ReqPerSec: .0363889
+BusyWorkers: 2
+IdleWorkers: 48
+ConnsTotal: 5
+Scoreboard: _W_K_R..
+`
+
+func TestParseServerStatus(t *testing.T) {
+	s, err := ParseServerStatus(strings.NewReader(serverStatus))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		got, want int64
+	}{
+		{"TotalAccesses", s.TotalAccesses, 131},
+		{"TotalKBytes", s.TotalKBytes, 256},
+		{"Uptime", s.Uptime, 3600},
+		{"BusyWorkers", s.BusyWorkers, 2},
+		{"IdleWorkers", s.IdleWorkers, 48},
+		{"ConnsTotal", s.ConnsTotal, 5},
+		{"waiting", s.Scoreboard["waiting"], 3},
+		{"sending", s.Scoreboard["sending"], 1},
+		{"keepalive", s.Scoreboard["keepalive"], 1},
+		{"reading", s.Scoreboard["reading"], 1},
+		{"open_slot", s.Scoreboard["open_slot"], 2},
+		{"closing", s.Scoreboard["closing"], 0},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("expected %s of %d; got %d", tt.name, tt.want, tt.got)
+		}
+	}
+	if got := len(s.Scoreboard); got != len(scoreboardStates) {
+		t.Errorf("expected all %d states in scoreboard; got %d", len(scoreboardStates), got)
+	}
+}
+
+func TestParseServerStatusInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"<html><body>It works!</body></html>\n",
+		"Total Accesses: many\n",
+	}
+	for _, input := range tests {
+		if _, err := ParseServerStatus(strings.NewReader(input)); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
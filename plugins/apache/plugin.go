@@ -0,0 +1,153 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package apache
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+const (
+	defaultURL     = "http://localhost/server-status?auto"
+	defaultTimeout = 10 * time.Second
+)
+
+// Config is the configuration for the Apache plugin.
+type Config struct {
+	// URLs of the server-status pages to watch
+	// (default: http://localhost/server-status?auto). The
+	// machine-readable format is requested if the URL has no query.
+	URLs []string
+
+	// Timeout for fetching a status page (default: 10s).
+	Timeout time.Duration
+}
+
+// Plugin watches one or more Apache servers via their server-status page.
+//
+// Rates are derived from the difference between two snapshots, so the
+// first snapshot reports zero rates.
+type Plugin struct {
+	urls     []string
+	client   *http.Client
+	last     map[string]*ServerStatus
+	lastTime time.Time
+}
+
+// NewPlugin initializes a new Plugin to watch Apache servers.
+func NewPlugin(config *Config) (*Plugin, error) {
+	if config == nil {
+		return nil, errors.New("no configuration specified")
+	}
+	urls := append([]string(nil), config.URLs...)
+	if len(urls) == 0 {
+		urls = []string{defaultURL}
+	}
+	for i, rawurl := range urls {
+		u, err := url.Parse(rawurl)
+		if err != nil {
+			return nil, err
+		}
+		if u.RawQuery == "" {
+			u.RawQuery = "auto"
+			urls[i] = u.String()
+		}
+	}
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Plugin{
+		urls:   urls,
+		client: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Name of the plugin.
+func (p *Plugin) Name() string {
+	return "apache"
+}
+
+// Snapshot returns the workers and request rates of all servers,
+// keyed by the URL of their status page. Servers that cannot be
+// reached are reported with critical health and the error.
+func (p *Plugin) Snapshot() (interface{}, error) {
+	now := time.Now()
+	secs := now.Sub(p.lastTime).Seconds()
+
+	data := make(map[string]interface{})
+	cur := make(map[string]*ServerStatus)
+	for _, u := range p.urls {
+		s, err := GetServerStatus(p.client, u)
+		if err != nil {
+			data[u] = map[string]interface{}{
+				"health": plugins.HealthCritical,
+				"error":  err.Error(),
+			}
+			continue
+		}
+		cur[u] = s
+		if prev, found := p.last[u]; found {
+			data[u] = p.server(u, s, prev, secs)
+		} else {
+			data[u] = p.server(u, s, s, 0)
+		}
+	}
+	p.last = cur
+	p.lastTime = now
+
+	// Return data
+	return data, nil
+}
+
+// server computes the metrics of a server from two samples taken secs
+// seconds apart, and updates the registered metrics.
+func (p *Plugin) server(rawurl string, cur, prev *ServerStatus, secs float64) map[string]interface{} {
+	prefix := "apache." + metricName(rawurl) + "."
+
+	values := map[string]int64{
+		"busy_workers": cur.BusyWorkers,
+		"idle_workers": cur.IdleWorkers,
+	}
+	rates := map[string]float64{
+		"requests_per_sec":    plugins.RateInt(cur.TotalAccesses, prev.TotalAccesses, secs),
+		"bytes_per_sec":       plugins.RateInt(cur.TotalKBytes, prev.TotalKBytes, secs) * 1024,
+		"connections_per_sec": plugins.RateInt(cur.ConnsTotal, prev.ConnsTotal, secs),
+	}
+
+	data := map[string]interface{}{"health": plugins.HealthOK}
+	for key, value := range values {
+		metrics.GetOrRegisterGauge(prefix+key, nil).Update(value)
+		data[key] = value
+	}
+	for key, value := range rates {
+		metrics.GetOrRegisterGaugeFloat64(prefix+key, nil).Update(value)
+		data[key] = value
+	}
+	scoreboard := make(map[string]interface{})
+	for state, n := range cur.Scoreboard {
+		metrics.GetOrRegisterGauge(prefix+"scoreboard."+state, nil).Update(n)
+		scoreboard[state] = n
+	}
+	data["scoreboard"] = scoreboard
+	data["uptime"] = cur.Uptime
+	return data
+}
+
+// metricName returns the name to use in metrics for a status page,
+// i.e. its host and port.
+func metricName(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil || u.Host == "" {
+		return rawurl
+	}
+	return u.Host
+}
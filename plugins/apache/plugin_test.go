@@ -0,0 +1,80 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package apache
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+func TestSnapshot(t *testing.T) {
+	var accesses int64 = 100
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/server-status" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.RawQuery != "auto" {
+			fmt.Fprint(w, "<html><body>Apache Status</body></html>")
+			return
+		}
+		n := atomic.AddInt64(&accesses, 50)
+		fmt.Fprintf(w, "Total Accesses: %d\nTotal kBytes: %d\nUptime: 60\nBusyWorkers: 1\nIdleWorkers: 9\nScoreboard: W_________\n", n, n)
+	}))
+	defer srv.Close()
+
+	// The machine-readable format is requested if the URL has no query
+	statusURL := srv.URL + "/server-status"
+	missingURL := srv.URL + "/missing?auto"
+	p, err := NewPlugin(&Config{URLs: []string{statusURL, missingURL}, Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, ok := data.(map[string]interface{})[statusURL+"?auto"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected server keyed by %s?auto; got %v", statusURL, data)
+	}
+	if got := server["health"]; got != plugins.HealthOK {
+		t.Errorf("expected health ok; got %v (%v)", got, server["error"])
+	}
+	if got := server["busy_workers"]; got != int64(1) {
+		t.Errorf("expected 1 busy worker; got %v", got)
+	}
+	if got := server["requests_per_sec"]; got != 0.0 {
+		t.Errorf("expected requests_per_sec of 0 on first snapshot; got %v", got)
+	}
+	scoreboard := server["scoreboard"].(map[string]interface{})
+	if got := scoreboard["waiting"]; got != int64(9) {
+		t.Errorf("expected 9 waiting workers; got %v", got)
+	}
+	missing := data.(map[string]interface{})[missingURL].(map[string]interface{})
+	if got := missing["health"]; got != plugins.HealthCritical {
+		t.Errorf("expected critical health for missing page; got %v", got)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	data, err = p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server = data.(map[string]interface{})[statusURL+"?auto"].(map[string]interface{})
+	if got := server["requests_per_sec"].(float64); got <= 0 {
+		t.Errorf("expected requests_per_sec > 0; got %v", got)
+	}
+	if got := server["bytes_per_sec"].(float64); got <= 0 {
+		t.Errorf("expected bytes_per_sec > 0; got %v", got)
+	}
+}
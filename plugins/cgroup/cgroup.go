@@ -0,0 +1,171 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package cgroup
+
+import (
+	"bufio"
+	"errors"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Stat is the resource usage of a cgroup. Counters are since the
+// creation of the cgroup.
+type Stat struct {
+	CPUUsage      uint64 // CPU time in microseconds
+	NrPeriods     uint64 // enforcement periods of the CPU quota
+	NrThrottled   uint64 // periods in which the cgroup was throttled
+	ThrottledTime uint64 // time throttled in microseconds
+
+	MemoryCurrent int64 // memory usage in bytes
+	MemoryMax     int64 // memory limit in bytes, or -1 if unlimited
+	OOMEvents     uint64
+	OOMKills      uint64
+
+	IOReadBytes  uint64
+	IOWriteBytes uint64
+}
+
+// IsV2 returns true if the cgroup filesystem mounted at root is the
+// unified (v2) hierarchy.
+func IsV2(root string) bool {
+	_, err := os.Stat(filepath.Join(root, "cgroup.controllers"))
+	return err == nil
+}
+
+// ReadV2 reads the resource usage of the cgroup in dir of a cgroup v2
+// hierarchy. Controllers that are not enabled for the cgroup are
+// reported as zero.
+func ReadV2(dir string) (*Stat, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+	s := &Stat{MemoryMax: -1}
+
+	cpu, _ := readKeyValues(filepath.Join(dir, "cpu.stat"))
+	s.CPUUsage = cpu["usage_usec"]
+	s.NrPeriods = cpu["nr_periods"]
+	s.NrThrottled = cpu["nr_throttled"]
+	s.ThrottledTime = cpu["throttled_usec"]
+
+	s.MemoryCurrent, _ = readInt(filepath.Join(dir, "memory.current"))
+	if max, err := readInt(filepath.Join(dir, "memory.max")); err == nil {
+		s.MemoryMax = max
+	}
+	events, _ := readKeyValues(filepath.Join(dir, "memory.events"))
+	s.OOMEvents = events["oom"]
+	s.OOMKills = events["oom_kill"]
+
+	// io.stat has lines like "8:0 rbytes=1459200 wbytes=314773504 ..."
+	if data, err := ioutil.ReadFile(filepath.Join(dir, "io.stat")); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			for _, field := range strings.Fields(line) {
+				kv := strings.SplitN(field, "=", 2)
+				if len(kv) != 2 {
+					continue
+				}
+				v, _ := strconv.ParseUint(kv[1], 10, 64)
+				switch kv[0] {
+				case "rbytes":
+					s.IOReadBytes += v
+				case "wbytes":
+					s.IOWriteBytes += v
+				}
+			}
+		}
+	}
+	return s, nil
+}
+
+// ReadV1 reads the resource usage of the cgroup at path rel in the
+// cgroup v1 hierarchies mounted below root, e.g. root/cpuacct/rel.
+func ReadV1(root, rel string) (*Stat, error) {
+	s := &Stat{MemoryMax: -1}
+	found := false
+
+	if usage, err := readInt(filepath.Join(root, "cpuacct", rel, "cpuacct.usage")); err == nil {
+		s.CPUUsage = uint64(usage) / 1000
+		found = true
+	}
+	if cpu, err := readKeyValues(filepath.Join(root, "cpu", rel, "cpu.stat")); err == nil {
+		s.NrPeriods = cpu["nr_periods"]
+		s.NrThrottled = cpu["nr_throttled"]
+		s.ThrottledTime = cpu["throttled_time"] / 1000
+		found = true
+	}
+
+	memDir := filepath.Join(root, "memory", rel)
+	if current, err := readInt(filepath.Join(memDir, "memory.usage_in_bytes")); err == nil {
+		s.MemoryCurrent = current
+		found = true
+	}
+	if max, err := readInt(filepath.Join(memDir, "memory.limit_in_bytes")); err == nil && max < math.MaxInt64/2 {
+		// No limit is reported as a huge, page-aligned number.
+		s.MemoryMax = max
+	}
+	if oom, err := readKeyValues(filepath.Join(memDir, "memory.oom_control")); err == nil {
+		s.OOMKills = oom["oom_kill"]
+		s.OOMEvents = s.OOMKills
+	}
+
+	// Lines like "8:0 Read 1459200", plus a "Total" line
+	filename := filepath.Join(root, "blkio", rel, "blkio.throttle.io_service_bytes")
+	if data, err := ioutil.ReadFile(filename); err == nil {
+		found = true
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) != 3 {
+				continue
+			}
+			v, _ := strconv.ParseUint(fields[2], 10, 64)
+			switch fields[1] {
+			case "Read":
+				s.IOReadBytes += v
+			case "Write":
+				s.IOWriteBytes += v
+			}
+		}
+	}
+
+	if !found {
+		return nil, errors.New("cgroup not found")
+	}
+	return s, nil
+}
+
+// readInt reads a file containing a single integer. It returns an
+// error for "max", which cgroup v2 uses for no limit.
+func readInt(filename string) (int64, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// readKeyValues reads a file with lines like "usage_usec 1234".
+func readKeyValues(filename string) (map[string]uint64, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if v, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+			values[fields[0]] = v
+		}
+	}
+	return values, scanner.Err()
+}
@@ -0,0 +1,128 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package cgroup
+
+import (
+	"path/filepath"
+	"testing"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+func TestReadV2(t *testing.T) {
+	root := filepath.Join("testdata", "v2", "fs", "cgroup")
+	if !IsV2(root) {
+		t.Fatalf("expected %s to be cgroup v2", root)
+	}
+	tests := []struct {
+		Cgroup   string
+		Expected Stat
+	}{
+		{
+			Cgroup: "system.slice/nginx.service",
+			Expected: Stat{
+				CPUUsage:      2500000,
+				NrPeriods:     100,
+				NrThrottled:   5,
+				ThrottledTime: 12000,
+				MemoryCurrent: 52428800,
+				MemoryMax:     104857600,
+				OOMEvents:     2,
+				OOMKills:      1,
+				IOReadBytes:   1459200 + 40800,
+				IOWriteBytes:  314773504 + 1000,
+			},
+		},
+		{
+			// No memory limit and no io controller
+			Cgroup: "system.slice/cron.service",
+			Expected: Stat{
+				CPUUsage:      1000,
+				MemoryCurrent: 1048576,
+				MemoryMax:     -1,
+			},
+		},
+	}
+	for _, test := range tests {
+		s, err := ReadV2(filepath.Join(root, test.Cgroup))
+		if err != nil {
+			t.Errorf("%s: %v", test.Cgroup, err)
+			continue
+		}
+		if *s != test.Expected {
+			t.Errorf("%s: expected %+v; got %+v", test.Cgroup, test.Expected, *s)
+		}
+	}
+
+	if _, err := ReadV2(filepath.Join(root, "system.slice", "gone.service")); err == nil {
+		t.Errorf("expected error for a removed cgroup")
+	}
+}
+
+func TestReadV1(t *testing.T) {
+	root := filepath.Join("testdata", "v1", "fs", "cgroup")
+	if IsV2(root) {
+		t.Fatalf("expected %s to be cgroup v1", root)
+	}
+	s, err := ReadV1(root, "docker/abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Stat{
+		CPUUsage:      2500000,
+		NrPeriods:     100,
+		NrThrottled:   5,
+		ThrottledTime: 12000,
+		MemoryCurrent: 52428800,
+		MemoryMax:     -1, // no limit
+		OOMEvents:     1,
+		OOMKills:      1,
+		IOReadBytes:   1459200 + 40800,
+		IOWriteBytes:  314773504 + 1000,
+	}
+	if *s != expected {
+		t.Errorf("expected %+v; got %+v", expected, *s)
+	}
+
+	if _, err := ReadV1(root, "docker/gone"); err == nil {
+		t.Errorf("expected error for a removed cgroup")
+	}
+}
+
+func TestSnapshotUnregistersRemovedCgroups(t *testing.T) {
+	defer func(root string) { plugins.SysRoot = root }(plugins.SysRoot)
+	plugins.SysRoot = filepath.Join("testdata", "v2")
+
+	p, err := NewPlugin(&Config{Cgroups: []string{"system.slice/*.service"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(data.(map[string]interface{})); got != 2 {
+		t.Fatalf("expected %d cgroups; got %d", 2, got)
+	}
+	if metrics.Get("cgroup.system.slice/cron.service.memory_current") == nil {
+		t.Fatalf("expected metrics of cron.service to be registered")
+	}
+
+	// cron.service is no longer watched, as if it had been stopped
+	p.patterns = []string{"system.slice/nginx.service"}
+	if _, err := p.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"memory_current", "oom_kills", "cpu_percent"} {
+		if metrics.Get("cgroup.system.slice/cron.service."+key) != nil {
+			t.Errorf("expected %s of cron.service to be unregistered", key)
+		}
+		if metrics.Get("cgroup.system.slice/nginx.service."+key) == nil {
+			t.Errorf("expected %s of nginx.service to be registered", key)
+		}
+	}
+}
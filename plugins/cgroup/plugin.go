@@ -0,0 +1,164 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package cgroup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+var (
+	// DefaultCgroups are the cgroups that are watched unless
+	// Config.Cgroups is set: systemd services and scopes (including
+	// those created by Docker with the systemd cgroup driver) and
+	// containers of Docker with the cgroupfs driver.
+	DefaultCgroups = []string{"system.slice/*.service", "system.slice/*.scope", "docker/*"}
+)
+
+// Config is the configuration for the cgroup plugin.
+type Config struct {
+	// Cgroups to watch, as patterns relative to /sys/fs/cgroup as
+	// used by path.Match. Defaults to DefaultCgroups if nil.
+	Cgroups []string
+}
+
+// Plugin watches the resource usage of cgroups, e.g. of containers
+// and systemd services. It supports cgroup v2 and falls back to v1.
+//
+// CPU usage and I/O rates are derived from the difference between two
+// snapshots, so the first snapshot reports zero rates.
+type Plugin struct {
+	patterns []string
+	last     map[string]*Stat
+	lastTime time.Time
+
+	// series are the names of the gauges registered by the last
+	// snapshot, so that those of removed cgroups can be unregistered.
+	series map[string]bool
+}
+
+// NewPlugin initializes a new Plugin to watch cgroups.
+func NewPlugin(config *Config) (*Plugin, error) {
+	if config == nil {
+		return nil, errors.New("no configuration specified")
+	}
+	patterns := config.Cgroups
+	if patterns == nil {
+		patterns = DefaultCgroups
+	}
+	for _, pattern := range patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, err
+		}
+	}
+	return &Plugin{patterns: patterns}, nil
+}
+
+// Name of the plugin.
+func (p *Plugin) Name() string {
+	return "cgroup"
+}
+
+// Snapshot returns the resource usage of all watched cgroups, keyed by
+// their path relative to the cgroup root.
+func (p *Plugin) Snapshot() (interface{}, error) {
+	cgroupRoot := plugins.SysPath("fs", "cgroup")
+	v2 := IsV2(cgroupRoot)
+	base := cgroupRoot
+	if !v2 {
+		// Enumerate cgroups in one of the v1 hierarchies
+		base = filepath.Join(cgroupRoot, "cpuacct")
+		if _, err := os.Stat(base); err != nil {
+			base = filepath.Join(cgroupRoot, "memory")
+		}
+	}
+	now := time.Now()
+	secs := now.Sub(p.lastTime).Seconds()
+
+	data := make(map[string]interface{})
+	cur := make(map[string]*Stat)
+	registered := make(map[string]bool)
+	for _, cgroup := range plugins.GlobDirs(base, p.patterns) {
+		var s *Stat
+		var err error
+		if v2 {
+			s, err = ReadV2(filepath.Join(cgroupRoot, cgroup))
+		} else {
+			s, err = ReadV1(cgroupRoot, cgroup)
+		}
+		if err != nil {
+			// cgroup has been removed in the meantime
+			continue
+		}
+		cur[cgroup] = s
+
+		if prev, found := p.last[cgroup]; found {
+			data[cgroup] = p.cgroup(cgroup, s, prev, secs, registered)
+		} else {
+			// Report zero rates until we have two samples.
+			data[cgroup] = p.cgroup(cgroup, s, s, 0, registered)
+		}
+	}
+	p.last = cur
+	p.lastTime = now
+
+	// Unregister the metrics of cgroups that are gone, e.g. of stopped
+	// containers, so that they do not pile up in the registry
+	for name := range p.series {
+		if !registered[name] {
+			metrics.Unregister(name)
+		}
+	}
+	p.series = registered
+
+	// Return data
+	return data, nil
+}
+
+// cgroup computes the metrics of a cgroup from two samples taken secs
+// seconds apart, and updates the registered metrics. The names of the
+// metrics are added to registered.
+func (p *Plugin) cgroup(name string, cur, prev *Stat, secs float64, registered map[string]bool) map[string]interface{} {
+	var throttledPercent float64
+	if periods := plugins.Delta(cur.NrPeriods, prev.NrPeriods); periods > 0 {
+		throttledPercent = plugins.Delta(cur.NrThrottled, prev.NrThrottled) / periods * 100.0
+	}
+
+	values := map[string]float64{
+		// 100% is one CPU core fully used
+		"cpu_percent":              plugins.PerSec(plugins.Delta(cur.CPUUsage, prev.CPUUsage), secs) / 1e6 * 100.0,
+		"throttled_percent":        throttledPercent,
+		"throttled_usec_per_sec":   plugins.PerSec(plugins.Delta(cur.ThrottledTime, prev.ThrottledTime), secs),
+		"io_read_bytes_per_sec":    plugins.PerSec(plugins.Delta(cur.IOReadBytes, prev.IOReadBytes), secs),
+		"io_written_bytes_per_sec": plugins.PerSec(plugins.Delta(cur.IOWriteBytes, prev.IOWriteBytes), secs),
+	}
+
+	prefix := "cgroup." + name + "."
+	data := make(map[string]interface{})
+	for key, value := range values {
+		metrics.GetOrRegisterGaugeFloat64(prefix+key, nil).Update(value)
+		registered[prefix+key] = true
+		data[key] = value
+	}
+	metrics.GetOrRegisterGauge(prefix+"memory_current", nil).Update(cur.MemoryCurrent)
+	metrics.GetOrRegisterGauge(prefix+"oom_kills", nil).Update(int64(cur.OOMKills))
+	registered[prefix+"memory_current"] = true
+	registered[prefix+"oom_kills"] = true
+
+	data["memory_current"] = cur.MemoryCurrent
+	data["memory_max"] = cur.MemoryMax
+	if cur.MemoryMax > 0 {
+		data["memory_percent"] = float64(cur.MemoryCurrent) / float64(cur.MemoryMax) * 100.0
+	}
+	data["oom_events"] = cur.OOMEvents
+	data["oom_kills"] = cur.OOMKills
+	return data
+}
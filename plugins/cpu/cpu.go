@@ -0,0 +1,36 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package cpu
+
+// Times is the time a CPU spent in the various states, in clock ticks.
+type Times struct {
+	User      uint64
+	Nice      uint64
+	System    uint64
+	Idle      uint64
+	IOWait    uint64
+	IRQ       uint64
+	SoftIRQ   uint64
+	Steal     uint64
+	Guest     uint64
+	GuestNice uint64
+}
+
+// Total returns the total time. Guest time is already accounted for in
+// User and Nice, so it is not added again.
+func (t Times) Total() uint64 {
+	return t.User + t.Nice + t.System + t.Idle + t.IOWait + t.IRQ + t.SoftIRQ + t.Steal
+}
+
+// Stat is a snapshot of the kernel/system statistics.
+type Stat struct {
+	Total           Times            // aggregated over all CPUs
+	CPUs            map[string]Times // per CPU, e.g. "cpu0"
+	ContextSwitches uint64           // since boot
+	Interrupts      uint64           // since boot
+	Forks           uint64           // since boot
+	ProcsRunning    uint64
+	ProcsBlocked    uint64
+}
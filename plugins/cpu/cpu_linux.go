@@ -0,0 +1,92 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+// +build linux
+
+package cpu
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+// GetStat returns the current CPU statistics from /proc/stat.
+func GetStat() (*Stat, error) {
+	f, err := os.Open(plugins.ProcPath("stat"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseStat(f)
+}
+
+// ParseStat parses the contents of /proc/stat.
+func ParseStat(r io.Reader) (*Stat, error) {
+	stat := &Stat{CPUs: make(map[string]Times)}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		switch key := fields[0]; {
+		case key == "cpu":
+			t, err := parseTimes(fields[1:])
+			if err != nil {
+				return nil, err
+			}
+			stat.Total = t
+		case strings.HasPrefix(key, "cpu"):
+			t, err := parseTimes(fields[1:])
+			if err != nil {
+				return nil, err
+			}
+			stat.CPUs[key] = t
+		case key == "ctxt":
+			stat.ContextSwitches, _ = strconv.ParseUint(fields[1], 10, 64)
+		case key == "intr":
+			stat.Interrupts, _ = strconv.ParseUint(fields[1], 10, 64)
+		case key == "processes":
+			stat.Forks, _ = strconv.ParseUint(fields[1], 10, 64)
+		case key == "procs_running":
+			stat.ProcsRunning, _ = strconv.ParseUint(fields[1], 10, 64)
+		case key == "procs_blocked":
+			stat.ProcsBlocked, _ = strconv.ParseUint(fields[1], 10, 64)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return stat, nil
+}
+
+// parseTimes parses the columns of a cpu line. Older kernels report
+// fewer columns; missing columns are left at zero.
+func parseTimes(fields []string) (Times, error) {
+	var t Times
+	dst := []*uint64{
+		&t.User, &t.Nice, &t.System, &t.Idle, &t.IOWait,
+		&t.IRQ, &t.SoftIRQ, &t.Steal, &t.Guest, &t.GuestNice,
+	}
+	if len(fields) < 4 {
+		return t, fmt.Errorf("invalid cpu line: %v", fields)
+	}
+	for i, field := range fields {
+		if i >= len(dst) {
+			break
+		}
+		v, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return t, err
+		}
+		*dst[i] = v
+	}
+	return t, nil
+}
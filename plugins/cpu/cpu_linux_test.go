@@ -0,0 +1,127 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+// +build linux
+
+package cpu
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+func TestParseStat(t *testing.T) {
+	defer func(root string) { plugins.ProcRoot = root }(plugins.ProcRoot)
+	plugins.ProcRoot = "testdata/first"
+
+	stat, err := GetStat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Times{User: 100, System: 100, Idle: 800}
+	if stat.Total != want {
+		t.Errorf("expected total %+v; got %+v", want, stat.Total)
+	}
+	if got := len(stat.CPUs); got != 2 {
+		t.Fatalf("expected 2 cpus; got %d", got)
+	}
+	want = Times{User: 50, System: 50, Idle: 400}
+	if got := stat.CPUs["cpu1"]; got != want {
+		t.Errorf("expected cpu1 %+v; got %+v", want, got)
+	}
+	if stat.ContextSwitches != 10000 {
+		t.Errorf("expected 10000 context switches; got %d", stat.ContextSwitches)
+	}
+	if stat.Interrupts != 5000 {
+		t.Errorf("expected 5000 interrupts; got %d", stat.Interrupts)
+	}
+	if stat.Forks != 200 {
+		t.Errorf("expected 200 forks; got %d", stat.Forks)
+	}
+	if stat.ProcsRunning != 2 || stat.ProcsBlocked != 1 {
+		t.Errorf("expected 2 running and 1 blocked; got %d and %d", stat.ProcsRunning, stat.ProcsBlocked)
+	}
+}
+
+func TestParseStatOldKernel(t *testing.T) {
+	stat, err := ParseStat(strings.NewReader("cpu  1 2 3 4\ncpu0 1 2 3 4\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Times{User: 1, Nice: 2, System: 3, Idle: 4}
+	if stat.Total != want {
+		t.Errorf("expected %+v; got %+v", want, stat.Total)
+	}
+
+	if _, err := ParseStat(strings.NewReader("cpu  1 2 3\n")); err == nil {
+		t.Error("expected error on cpu line with too few columns")
+	}
+	if _, err := ParseStat(strings.NewReader("cpu  1 2 3 x\n")); err == nil {
+		t.Error("expected error on cpu line with invalid column")
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	defer func(root string) { plugins.ProcRoot = root }(plugins.ProcRoot)
+
+	p, err := NewPlugin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first snapshot reports the averages since boot and zero rates.
+	plugins.ProcRoot = "testdata/first"
+	data, err := p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := data.(map[string]interface{})
+	total := m["total"].(map[string]interface{})
+	if got := total["user"]; got != 10.0 {
+		t.Errorf("expected user 10%%; got %v", got)
+	}
+	if got := total["idle"]; got != 80.0 {
+		t.Errorf("expected idle 80%%; got %v", got)
+	}
+	for _, key := range []string{"context_switches_per_sec", "interrupts_per_sec", "forks_per_sec"} {
+		if got := m[key]; got != 0.0 {
+			t.Errorf("expected %s of 0 on first snapshot; got %v", key, got)
+		}
+	}
+
+	// The second snapshot reports the difference. Counters that went
+	// backwards, e.g. after a wrap, report zero instead of a huge value.
+	plugins.ProcRoot = "testdata/wrapped"
+	data, err = p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = data.(map[string]interface{})
+	total = m["total"].(map[string]interface{})
+	if got := total["user"]; got != 10.0 {
+		t.Errorf("expected user 10%%; got %v", got)
+	}
+	cpus := m["cpus"].(map[string]interface{})
+	cpu0 := cpus["cpu0"].(map[string]interface{})
+	if got := cpu0["user"]; got != 10.0 {
+		t.Errorf("expected cpu0 user 10%%; got %v", got)
+	}
+	cpu1 := cpus["cpu1"].(map[string]interface{})
+	for state, v := range cpu1 {
+		if v != 0.0 {
+			t.Errorf("expected cpu1 %s of 0 after wrap; got %v", state, v)
+		}
+	}
+	if got := m["context_switches_per_sec"]; got != 0.0 {
+		t.Errorf("expected context_switches_per_sec of 0 after wrap; got %v", got)
+	}
+	if got := m["interrupts_per_sec"].(float64); got <= 0 {
+		t.Errorf("expected interrupts_per_sec > 0; got %v", got)
+	}
+	if got := m["procs_running"]; got != uint64(3) {
+		t.Errorf("expected 3 running procs; got %v", got)
+	}
+}
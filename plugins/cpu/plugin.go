@@ -0,0 +1,112 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package cpu
+
+import (
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+// Plugin watches the CPU utilisation of a machine.
+//
+// Percentages and rates are computed from the difference between two
+// snapshots. The first snapshot reports the averages since boot and
+// zero rates.
+type Plugin struct {
+	last     *Stat
+	lastTime time.Time
+
+	ContextSwitches metrics.GaugeFloat64 // context switches per second
+	Interrupts      metrics.GaugeFloat64 // interrupts per second
+	Forks           metrics.GaugeFloat64 // forks per second
+}
+
+// NewPlugin initializes a new Plugin to watch the CPU utilisation.
+func NewPlugin() (*Plugin, error) {
+	p := &Plugin{}
+	p.ContextSwitches = metrics.NewGaugeFloat64()
+	metrics.Register("cpu.context_switches_per_sec", p.ContextSwitches)
+	p.Interrupts = metrics.NewGaugeFloat64()
+	metrics.Register("cpu.interrupts_per_sec", p.Interrupts)
+	p.Forks = metrics.NewGaugeFloat64()
+	metrics.Register("cpu.forks_per_sec", p.Forks)
+	return p, nil
+}
+
+// Name is the name of the plugin.
+func (p *Plugin) Name() string {
+	return "cpu"
+}
+
+// Snapshot returns the CPU utilisation since the last snapshot.
+func (p *Plugin) Snapshot() (interface{}, error) {
+	stat, err := GetStat()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	prev := p.last
+	if prev == nil {
+		prev = &Stat{CPUs: make(map[string]Times)}
+	}
+
+	// Update metrics
+	if p.last != nil {
+		secs := now.Sub(p.lastTime).Seconds()
+		p.ContextSwitches.Update(plugins.Rate(stat.ContextSwitches, prev.ContextSwitches, secs))
+		p.Interrupts.Update(plugins.Rate(stat.Interrupts, prev.Interrupts, secs))
+		p.Forks.Update(plugins.Rate(stat.Forks, prev.Forks, secs))
+	}
+	p.last = stat
+	p.lastTime = now
+
+	cpus := make(map[string]interface{})
+	for name, times := range stat.CPUs {
+		cpus[name] = percents(name, times, prev.CPUs[name])
+	}
+
+	// Return data
+	return map[string]interface{}{
+		"total":                    percents("total", stat.Total, prev.Total),
+		"cpus":                     cpus,
+		"num_cpus":                 len(stat.CPUs),
+		"procs_running":            stat.ProcsRunning,
+		"procs_blocked":            stat.ProcsBlocked,
+		"context_switches_per_sec": p.ContextSwitches.Value(),
+		"interrupts_per_sec":       p.Interrupts.Value(),
+		"forks_per_sec":            p.Forks.Value(),
+	}, nil
+}
+
+// percents returns the percentage of time spent in each state between
+// prev and cur, and updates the metrics of the given CPU.
+func percents(name string, cur, prev Times) map[string]interface{} {
+	var total float64
+	if cur.Total() > prev.Total() {
+		total = float64(cur.Total() - prev.Total())
+	}
+	pct := func(state string, c, p uint64) float64 {
+		var v float64
+		if total > 0 && c >= p {
+			v = float64(c-p) / total * 100.0
+		}
+		metrics.GetOrRegisterGaugeFloat64("cpu."+name+"."+state, nil).Update(v)
+		return v
+	}
+	return map[string]interface{}{
+		"user":    pct("user", cur.User, prev.User),
+		"nice":    pct("nice", cur.Nice, prev.Nice),
+		"system":  pct("system", cur.System, prev.System),
+		"idle":    pct("idle", cur.Idle, prev.Idle),
+		"iowait":  pct("iowait", cur.IOWait, prev.IOWait),
+		"irq":     pct("irq", cur.IRQ, prev.IRQ),
+		"softirq": pct("softirq", cur.SoftIRQ, prev.SoftIRQ),
+		"steal":   pct("steal", cur.Steal, prev.Steal),
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package disk
+
+// Mount is a mounted filesystem.
+type Mount struct {
+	MountPoint string
+	FSType     string
+	Device     string
+}
+
+// Usage is the space and inode usage of a mounted filesystem.
+type Usage struct {
+	Mount
+
+	Total       uint64 // size in bytes
+	Free        uint64 // free bytes
+	Avail       uint64 // free bytes available to unprivileged users
+	Used        uint64 // used bytes
+	UsedPercent float64
+
+	Inodes            uint64
+	InodesFree        uint64
+	InodesUsed        uint64
+	InodesUsedPercent float64
+}
@@ -0,0 +1,19 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+// +build darwin
+
+package disk
+
+import "errors"
+
+// GetMounts is not supported on Darwin.
+func GetMounts() ([]Mount, error) {
+	return nil, errors.New("disk: not supported on darwin")
+}
+
+// GetUsage is not supported on Darwin.
+func GetUsage(m Mount) (*Usage, error) {
+	return nil, errors.New("disk: not supported on darwin")
+}
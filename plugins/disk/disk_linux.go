@@ -0,0 +1,120 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+// +build linux
+
+package disk
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+// GetMounts returns the mounted filesystems from /proc/self/mountinfo.
+// If a host root is configured, they are read from /proc/1/mountinfo
+// instead, i.e. the mounts as seen by init, so that the host's
+// filesystems are reported when running in a container.
+func GetMounts() ([]Mount, error) {
+	f, err := os.Open(plugins.NamespacePath("mountinfo"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseMountInfo(f)
+}
+
+// ParseMountInfo parses the contents of /proc/<pid>/mountinfo.
+// If a mount point is listed more than once, the last mount wins.
+// See proc(5) for a description of the format.
+func ParseMountInfo(r io.Reader) ([]Mount, error) {
+	var mounts []Mount
+	index := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw
+		parts := strings.SplitN(line, " - ", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid mountinfo line %q", line)
+		}
+		fields := strings.Fields(parts[0])
+		extra := strings.Fields(parts[1])
+		if len(fields) < 5 || len(extra) < 2 {
+			return nil, fmt.Errorf("invalid mountinfo line %q", line)
+		}
+		m := Mount{
+			MountPoint: unescape(fields[4]),
+			FSType:     extra[0],
+			Device:     unescape(extra[1]),
+		}
+		if i, found := index[m.MountPoint]; found {
+			mounts[i] = m
+		} else {
+			index[m.MountPoint] = len(mounts)
+			mounts = append(mounts, m)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return mounts, nil
+}
+
+// unescape replaces the octal escapes like \040 that the kernel uses for
+// spaces and other special characters in paths.
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// GetUsage returns the usage of the filesystem mounted at m. The mount
+// point is looked up below plugins.HostRoot.
+func GetUsage(m Mount) (*Usage, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(plugins.HostPath(m.MountPoint), &st); err != nil {
+		return nil, err
+	}
+	bsize := uint64(st.Bsize)
+	u := &Usage{
+		Mount:      m,
+		Total:      st.Blocks * bsize,
+		Free:       st.Bfree * bsize,
+		Avail:      st.Bavail * bsize,
+		Inodes:     st.Files,
+		InodesFree: st.Ffree,
+	}
+	u.Used = u.Total - u.Free
+	if u.Used+u.Avail > 0 {
+		// Same as df: relative to the space available to users.
+		u.UsedPercent = float64(u.Used) / float64(u.Used+u.Avail) * 100.0
+	}
+	u.InodesUsed = u.Inodes - u.InodesFree
+	if u.Inodes > 0 {
+		u.InodesUsedPercent = float64(u.InodesUsed) / float64(u.Inodes) * 100.0
+	}
+	return u, nil
+}
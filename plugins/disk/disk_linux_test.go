@@ -0,0 +1,74 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+// +build linux
+
+package disk
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+func TestGetMounts(t *testing.T) {
+	defer func(root string) { plugins.ProcRoot = root }(plugins.ProcRoot)
+	plugins.ProcRoot = "testdata/proc"
+
+	// Without a host root, the mounts of metronomed itself are reported
+	mounts, err := GetMounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Mount{
+		{MountPoint: "/", FSType: "overlay", Device: "overlay"},
+		{MountPoint: "/proc", FSType: "proc", Device: "proc"},
+	}
+	if !reflect.DeepEqual(mounts, want) {
+		t.Errorf("expected\n%+v\ngot\n%+v", want, mounts)
+	}
+
+	// With a host root, the mounts of init are reported
+	defer func(root string) { plugins.HostRoot = root }(plugins.HostRoot)
+	plugins.HostRoot = "testdata/host"
+
+	mounts, err = GetMounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []Mount{
+		{MountPoint: "/", FSType: "ext4", Device: "/dev/sda1"},
+		{MountPoint: "/proc", FSType: "proc", Device: "proc"},
+		{MountPoint: "/data", FSType: "ext4", Device: "/dev/sdc1"}, // last mount wins
+		{MountPoint: "/mnt/my disk", FSType: "ext4", Device: "/dev/sdb1"},
+	}
+	if !reflect.DeepEqual(mounts, want) {
+		t.Errorf("expected\n%+v\ngot\n%+v", want, mounts)
+	}
+}
+
+func TestGetUsage(t *testing.T) {
+	defer func(root string) { plugins.HostRoot = root }(plugins.HostRoot)
+	plugins.HostRoot = "testdata/host"
+
+	u, err := GetUsage(Mount{MountPoint: "/data", FSType: "ext4", Device: "/dev/sdc1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Total == 0 {
+		t.Error("expected total > 0")
+	}
+	if u.Used+u.Free != u.Total {
+		t.Errorf("expected used + free = total; got %d + %d != %d", u.Used, u.Free, u.Total)
+	}
+	if u.UsedPercent < 0 || u.UsedPercent > 100 {
+		t.Errorf("expected used percent between 0 and 100; got %v", u.UsedPercent)
+	}
+
+	// The mount point must be looked up below the host root
+	if _, err := GetUsage(Mount{MountPoint: "/proc"}); err == nil {
+		t.Error("expected error for mount point missing below the host root")
+	}
+}
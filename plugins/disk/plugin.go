@@ -0,0 +1,164 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package disk
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+var (
+	defaultTimeout = 5 * time.Second
+
+	// DefaultExcludeFSTypes are the pseudo and in-memory filesystems that
+	// are skipped unless Config.ExcludeFSTypes is set.
+	DefaultExcludeFSTypes = []string{
+		"autofs", "binfmt_misc", "bpf", "cgroup", "cgroup2", "configfs",
+		"debugfs", "devpts", "devtmpfs", "fusectl", "hugetlbfs", "mqueue",
+		"nsfs", "overlay", "proc", "pstore", "ramfs", "rpc_pipefs",
+		"securityfs", "squashfs", "sysfs", "tmpfs", "tracefs",
+	}
+)
+
+// Config is the configuration for the disk plugin. Mount points and
+// filesystem types are selected by patterns as used by path.Match.
+type Config struct {
+	IncludeMountPoints []string
+	ExcludeMountPoints []string
+	IncludeFSTypes     []string
+	// ExcludeFSTypes defaults to DefaultExcludeFSTypes if nil.
+	ExcludeFSTypes []string
+	// Timeout to get the usage of a single filesystem (default: 5s).
+	Timeout time.Duration
+}
+
+// Plugin watches the filesystem usage of a machine.
+type Plugin struct {
+	mountPoints *plugins.Filter
+	fsTypes     *plugins.Filter
+	timeout     time.Duration
+
+	mu      sync.Mutex
+	pending map[string]bool // mount points with a hanging statfs
+}
+
+// NewPlugin initializes a new Plugin to watch the filesystem usage.
+func NewPlugin(config *Config) (*Plugin, error) {
+	if config == nil {
+		return nil, errors.New("no configuration specified")
+	}
+	excludeFSTypes := config.ExcludeFSTypes
+	if excludeFSTypes == nil {
+		excludeFSTypes = DefaultExcludeFSTypes
+	}
+
+	p := &Plugin{
+		timeout: config.Timeout,
+		pending: make(map[string]bool),
+	}
+	if p.timeout <= 0 {
+		p.timeout = defaultTimeout
+	}
+	var err error
+	p.mountPoints, err = plugins.NewFilter(config.IncludeMountPoints, config.ExcludeMountPoints)
+	if err != nil {
+		return nil, err
+	}
+	p.fsTypes, err = plugins.NewFilter(config.IncludeFSTypes, excludeFSTypes)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Name of the plugin.
+func (p *Plugin) Name() string {
+	return "disk"
+}
+
+// Snapshot returns the usage of all selected filesystems, keyed by
+// mount point.
+func (p *Plugin) Snapshot() (interface{}, error) {
+	mounts, err := GetMounts()
+	if err != nil {
+		return nil, err
+	}
+
+	data := make(map[string]interface{})
+	for _, m := range mounts {
+		if !p.mountPoints.Match(m.MountPoint) || !p.fsTypes.Match(m.FSType) {
+			continue
+		}
+		u, err := p.usage(m)
+		if err != nil {
+			// E.g. permission denied or a stale network mount
+			continue
+		}
+
+		// Update metrics
+		prefix := "disk." + m.MountPoint + "."
+		metrics.GetOrRegisterGauge(prefix+"total", nil).Update(int64(u.Total))
+		metrics.GetOrRegisterGauge(prefix+"used", nil).Update(int64(u.Used))
+		metrics.GetOrRegisterGauge(prefix+"free", nil).Update(int64(u.Avail))
+		metrics.GetOrRegisterGaugeFloat64(prefix+"used_percent", nil).Update(u.UsedPercent)
+		metrics.GetOrRegisterGaugeFloat64(prefix+"inodes_used_percent", nil).Update(u.InodesUsedPercent)
+
+		data[m.MountPoint] = map[string]interface{}{
+			"device":              u.Device,
+			"fstype":              u.FSType,
+			"total":               u.Total,
+			"used":                u.Used,
+			"free":                u.Avail,
+			"used_percent":        u.UsedPercent,
+			"inodes_total":        u.Inodes,
+			"inodes_used":         u.InodesUsed,
+			"inodes_free":         u.InodesFree,
+			"inodes_used_percent": u.InodesUsedPercent,
+		}
+	}
+
+	// Return data
+	return data, nil
+}
+
+// usage returns the usage of the filesystem mounted at m. Statfs on a
+// stale network mount blocks indefinitely, so it gives up after the
+// timeout. No new statfs is started for a mount point as long as the
+// previous one is still hanging.
+func (p *Plugin) usage(m Mount) (*Usage, error) {
+	p.mu.Lock()
+	if p.pending[m.MountPoint] {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("filesystem %s is not responding", m.MountPoint)
+	}
+	p.pending[m.MountPoint] = true
+	p.mu.Unlock()
+
+	type result struct {
+		u   *Usage
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		u, err := GetUsage(m)
+		p.mu.Lock()
+		delete(p.pending, m.MountPoint)
+		p.mu.Unlock()
+		ch <- result{u, err}
+	}()
+
+	select {
+	case r := <-ch:
+		return r.u, r.err
+	case <-time.After(p.timeout):
+		return nil, fmt.Errorf("filesystem %s timed out after %v", m.MountPoint, p.timeout)
+	}
+}
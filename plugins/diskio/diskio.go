@@ -0,0 +1,25 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package diskio
+
+// SectorSize is the size of a sector as used in the kernel statistics,
+// independent of the actual sector size of the device.
+const SectorSize = 512
+
+// Stat is the I/O statistics of a block device since boot.
+type Stat struct {
+	Name           string
+	Reads          uint64 // reads completed
+	ReadsMerged    uint64
+	SectorsRead    uint64
+	ReadTime       uint64 // milliseconds spent reading
+	Writes         uint64 // writes completed
+	WritesMerged   uint64
+	SectorsWritten uint64
+	WriteTime      uint64 // milliseconds spent writing
+	InProgress     uint64 // I/Os currently in progress
+	IOTime         uint64 // milliseconds spent doing I/Os
+	WeightedIOTime uint64 // weighted milliseconds spent doing I/Os
+}
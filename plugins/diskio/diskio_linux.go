@@ -0,0 +1,63 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+// +build linux
+
+package diskio
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+// GetStats returns the I/O statistics of all block devices from
+// /proc/diskstats.
+func GetStats() ([]Stat, error) {
+	f, err := os.Open(plugins.ProcPath("diskstats"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseDiskStats(f)
+}
+
+// ParseDiskStats parses the contents of /proc/diskstats.
+// See Documentation/iostats.txt in the kernel sources for the format.
+func ParseDiskStats(r io.Reader) ([]Stat, error) {
+	var stats []Stat
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 14 {
+			return nil, fmt.Errorf("invalid diskstats line: %v", fields)
+		}
+		s := Stat{Name: fields[2]}
+		dst := []*uint64{
+			&s.Reads, &s.ReadsMerged, &s.SectorsRead, &s.ReadTime,
+			&s.Writes, &s.WritesMerged, &s.SectorsWritten, &s.WriteTime,
+			&s.InProgress, &s.IOTime, &s.WeightedIOTime,
+		}
+		for i, p := range dst {
+			v, err := strconv.ParseUint(fields[3+i], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			*p = v
+		}
+		stats = append(stats, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
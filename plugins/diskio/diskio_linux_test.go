@@ -0,0 +1,62 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+//go:build linux
+// +build linux
+
+package diskio
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+func TestParseDiskStats(t *testing.T) {
+	defer func(root string) { plugins.ProcRoot = root }(plugins.ProcRoot)
+	plugins.ProcRoot = "testdata/proc"
+
+	stats, err := GetStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Stat{
+		{
+			Name:  "sda",
+			Reads: 12345, ReadsMerged: 678, SectorsRead: 987654, ReadTime: 4321,
+			Writes: 23456, WritesMerged: 789, SectorsWritten: 1234567, WriteTime: 8765,
+			InProgress: 0, IOTime: 9876, WeightedIOTime: 13086,
+		},
+		{
+			Name:  "sda1",
+			Reads: 12000, ReadsMerged: 600, SectorsRead: 980000, ReadTime: 4200,
+			Writes: 23000, WritesMerged: 700, SectorsWritten: 1230000, WriteTime: 8700,
+			InProgress: 0, IOTime: 9800, WeightedIOTime: 12900,
+		},
+		// Kernels before 4.18 have no discard and flush columns
+		{
+			Name:  "nvme0n1",
+			Reads: 500, ReadsMerged: 10, SectorsRead: 40000, ReadTime: 120,
+			Writes: 800, WritesMerged: 20, SectorsWritten: 64000, WriteTime: 300,
+			InProgress: 2, IOTime: 400, WeightedIOTime: 420,
+		},
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("expected\n%+v\ngot\n%+v", want, stats)
+	}
+}
+
+func TestParseDiskStatsInvalid(t *testing.T) {
+	tests := []string{
+		"   8       0 sda 1 2 3 4 5 6 7\n",
+		"   8       0 sda 1 2 3 4 5 6 7 8 9 10 x\n",
+	}
+	for _, input := range tests {
+		if _, err := ParseDiskStats(strings.NewReader(input)); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package diskio
+
+import (
+	"errors"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+var (
+	// DefaultExcludeDevices are the devices that are skipped unless
+	// Config.ExcludeDevices is set.
+	DefaultExcludeDevices = []string{"loop*", "ram*", "zram*"}
+)
+
+// Config is the configuration for the diskio plugin. Devices are
+// selected by patterns as used by path.Match, e.g. "sd*".
+type Config struct {
+	IncludeDevices []string
+	// ExcludeDevices defaults to DefaultExcludeDevices if nil.
+	ExcludeDevices []string
+}
+
+// Plugin watches the I/O of the block devices of a machine.
+//
+// All values are derived from the difference between two snapshots,
+// so the first snapshot reports zeros.
+type Plugin struct {
+	devices  *plugins.Filter
+	last     map[string]Stat
+	lastTime time.Time
+}
+
+// NewPlugin initializes a new Plugin to watch the disk I/O.
+func NewPlugin(config *Config) (*Plugin, error) {
+	if config == nil {
+		return nil, errors.New("no configuration specified")
+	}
+	excludeDevices := config.ExcludeDevices
+	if excludeDevices == nil {
+		excludeDevices = DefaultExcludeDevices
+	}
+	devices, err := plugins.NewFilter(config.IncludeDevices, excludeDevices)
+	if err != nil {
+		return nil, err
+	}
+	return &Plugin{devices: devices}, nil
+}
+
+// Name of the plugin.
+func (p *Plugin) Name() string {
+	return "diskio"
+}
+
+// Snapshot returns the I/O of all selected devices since the last
+// snapshot, keyed by device name.
+func (p *Plugin) Snapshot() (interface{}, error) {
+	stats, err := GetStats()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	secs := now.Sub(p.lastTime).Seconds()
+
+	data := make(map[string]interface{})
+	cur := make(map[string]Stat)
+	for _, s := range stats {
+		if !p.devices.Match(s.Name) {
+			continue
+		}
+		cur[s.Name] = s
+
+		if prev, found := p.last[s.Name]; found {
+			data[s.Name] = p.device(s, prev, secs)
+		} else {
+			// Report zeros until we have two samples.
+			data[s.Name] = p.device(s, s, 0)
+		}
+	}
+	p.last = cur
+	p.lastTime = now
+
+	// Return data
+	return data, nil
+}
+
+// device computes the metrics of a device from two samples taken secs
+// seconds apart, and updates the registered metrics.
+func (p *Plugin) device(cur, prev Stat, secs float64) map[string]interface{} {
+	reads := plugins.Delta(cur.Reads, prev.Reads)
+	writes := plugins.Delta(cur.Writes, prev.Writes)
+	ioTime := plugins.Delta(cur.IOTime, prev.IOTime)
+
+	var await, utilization float64
+	if reads+writes > 0 {
+		await = plugins.Delta(cur.ReadTime, prev.ReadTime) + plugins.Delta(cur.WriteTime, prev.WriteTime)
+		await /= reads + writes
+	}
+	if secs > 0 {
+		utilization = ioTime / (secs * 1000) * 100.0
+		if utilization > 100.0 {
+			utilization = 100.0
+		}
+	}
+
+	values := map[string]float64{
+		"reads_per_sec":         plugins.PerSec(reads, secs),
+		"writes_per_sec":        plugins.PerSec(writes, secs),
+		"read_bytes_per_sec":    plugins.PerSec(plugins.Delta(cur.SectorsRead, prev.SectorsRead)*SectorSize, secs),
+		"written_bytes_per_sec": plugins.PerSec(plugins.Delta(cur.SectorsWritten, prev.SectorsWritten)*SectorSize, secs),
+		"avg_queue_depth":       plugins.PerSec(plugins.Delta(cur.WeightedIOTime, prev.WeightedIOTime), secs) / 1000,
+		"await_ms":              await,
+		"util_percent":          utilization,
+	}
+
+	data := make(map[string]interface{})
+	for key, value := range values {
+		metrics.GetOrRegisterGaugeFloat64("diskio."+cur.Name+"."+key, nil).Update(value)
+		data[key] = value
+	}
+	data["in_progress"] = cur.InProgress
+	return data
+}
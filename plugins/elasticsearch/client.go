@@ -0,0 +1,80 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package elasticsearch
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/olivere/elastic"
+)
+
+// clientOptions returns the options to create a client with the
+// given configuration.
+func clientOptions(config *Config, timeout time.Duration) ([]elastic.ClientOptionFunc, error) {
+	if config.Username != "" && config.APIKey != "" {
+		return nil, errors.New("specify either username or api key, not both")
+	}
+
+	// Clone the default transport to keep its dialer, proxy settings
+	// and idle connection timeouts.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.CAFile != "" {
+		pem, err := ioutil.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", config.CAFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	var rt http.RoundTripper = transport
+	if config.APIKey != "" {
+		rt = &apiKeyTransport{apiKey: config.APIKey, next: transport}
+	}
+
+	options := []elastic.ClientOptionFunc{
+		elastic.SetURL(config.Urls...),
+		elastic.SetHttpClient(&http.Client{Transport: rt, Timeout: timeout}),
+		elastic.SetHealthcheckTimeoutStartup(timeout),
+		elastic.SetSnifferTimeoutStartup(timeout),
+	}
+	if config.Username != "" {
+		options = append(options, elastic.SetBasicAuth(config.Username, config.Password))
+	}
+	if config.Sniff != nil {
+		options = append(options, elastic.SetSniff(*config.Sniff))
+	}
+	if config.Healthcheck != nil {
+		options = append(options, elastic.SetHealthcheck(*config.Healthcheck))
+	}
+	return options, nil
+}
+
+// apiKeyTransport authenticates requests with an API key.
+type apiKeyTransport struct {
+	apiKey string
+	next   http.RoundTripper
+}
+
+// RoundTrip adds the Authorization header to a copy of the request, as
+// a RoundTripper must not modify the request.
+func (t *apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := new(http.Request)
+	*r = *req
+	r.Header = make(http.Header, len(req.Header)+1)
+	for k, v := range req.Header {
+		r.Header[k] = v
+	}
+	r.Header.Set("Authorization", "ApiKey "+t.apiKey)
+	return t.next.RoundTrip(r)
+}
@@ -7,22 +7,88 @@ package elasticsearch
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/olivere/elastic"
 	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+const (
+	defaultTimeout = 10 * time.Second
+
+	// Backoff between failed attempts to create the client
+	minClientBackoff = 1 * time.Second
+	maxClientBackoff = 1 * time.Minute
 )
 
 // Config is the configuration for the Elasticsearch plugin.
 type Config struct {
 	// Urls of the cluster to watch with the plugin.
 	Urls []string
+
+	// Username and Password for HTTP basic authentication.
+	Username string
+	Password string
+
+	// APIKey to authenticate with, as the base64-encoded "id:api_key"
+	// returned by the create API key API. Mutually exclusive with
+	// Username.
+	APIKey string
+
+	// CAFile is a PEM file with the certificate authorities to verify
+	// the cluster with when using https (default: system roots).
+	CAFile string
+
+	// Timeout for a single request to the cluster (default: 10s).
+	Timeout time.Duration
+
+	// Sniff and Healthcheck enable or disable sniffing the cluster for
+	// its nodes and checking their health periodically. Both are enabled
+	// if nil. Disable sniffing if the nodes are not reachable under
+	// their published addresses, e.g. behind a proxy or in containers.
+	Sniff       *bool
+	Healthcheck *bool
+
+	// Nodes are patterns of node names to report per-node stats of,
+	// e.g. "es-data-*". No per-node stats are reported if empty. Nodes
+	// are reported by node ID, with their name as a field.
+	Nodes []string
+
+	// Indices are patterns of index names to report per-index stats of,
+	// e.g. "logstash-*". No per-index stats are reported if empty.
+	Indices []string
 }
 
 // Plugin that watches an Elasticsearch cluster.
 type Plugin struct {
-	name   string          // cluster name
-	urls   []string        // URLs of the cluster
-	client *elastic.Client // Elastic client
+	name    string                     // cluster name
+	urls    []string                   // URLs of the cluster
+	options []elastic.ClientOptionFunc // options to create the client with
+
+	mu            sync.Mutex
+	client        *elastic.Client // Elastic client, created on first use
+	clientErr     error           // error of the last attempt to create the client
+	clientBackoff time.Duration   // time to wait after a failed attempt
+	nextClient    time.Time       // time of the next attempt to create the client
+
+	nodes       []string               // patterns of nodes to report
+	indices     []string               // patterns of indices to report
+	lastNodes   map[string]*NodeStats  // node stats of the last snapshot
+	lastIndices map[string]*IndexStats // index stats of the last snapshot
+	lastStats   *Stats                 // cluster stats of the last snapshot
+	lastTime    time.Time              // time of the last snapshot
+
+	// Names of the per-node and per-index gauges registered by the last
+	// snapshot, so that those of nodes and indices that are gone can be
+	// unregistered.
+	nodeSeries  map[string]bool
+	indexSeries map[string]bool
+
+	Status metrics.Gauge // cluster status, see StatusCode
 
 	NumNodes     metrics.Gauge // number of nodes in the cluster
 	NumDataNodes metrics.Gauge // number of data nodes in the cluster
@@ -45,6 +111,10 @@ type Plugin struct {
 	OFDMin metrics.Gauge // min open file descriptors (all nodes)
 	OFDMax metrics.Gauge // max open file descriptors (all nodes)
 	OFDAvg metrics.Gauge // avg open file descriptors (all nodes)
+
+	IndexingRate metrics.GaugeFloat64 // documents indexed per second
+	SearchRate   metrics.GaugeFloat64 // queries per second
+	MergeRate    metrics.GaugeFloat64 // merges per second
 }
 
 // NewPlugin initializes a new watcher for an Elasticsearch cluster.
@@ -57,16 +127,24 @@ func NewPlugin(name string, config *Config) (*Plugin, error) {
 		return nil, errors.New("no configuration specified")
 	}
 
-	client, err := elastic.NewClient(elastic.SetURL(config.Urls...))
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	options, err := clientOptions(config, timeout)
 	if err != nil {
 		return nil, err
 	}
 	plugin := &Plugin{
-		name:   name,
-		urls:   config.Urls,
-		client: client,
+		name:    name,
+		urls:    config.Urls,
+		options: options,
+		nodes:   config.Nodes,
+		indices: config.Indices,
 	}
 
+	plugin.Status = metrics.NewGauge()
+	metrics.Register(fmt.Sprintf("elasticsearch.%s.status", plugin.name), plugin.Status)
 	plugin.NumNodes = metrics.NewGauge()
 	metrics.Register(fmt.Sprintf("elasticsearch.%s.num_nodes", plugin.name), plugin.NumNodes)
 	plugin.NumDataNodes = metrics.NewGauge()
@@ -102,6 +180,13 @@ func NewPlugin(name string, config *Config) (*Plugin, error) {
 	plugin.OFDAvg = metrics.NewGauge()
 	metrics.Register(fmt.Sprintf("elasticsearch.%s.open_file_descriptors.avg", plugin.name), plugin.OFDAvg)
 
+	plugin.IndexingRate = metrics.NewGaugeFloat64()
+	metrics.Register(fmt.Sprintf("elasticsearch.%s.indexing_per_sec", plugin.name), plugin.IndexingRate)
+	plugin.SearchRate = metrics.NewGaugeFloat64()
+	metrics.Register(fmt.Sprintf("elasticsearch.%s.search_per_sec", plugin.name), plugin.SearchRate)
+	plugin.MergeRate = metrics.NewGaugeFloat64()
+	metrics.Register(fmt.Sprintf("elasticsearch.%s.merges_per_sec", plugin.name), plugin.MergeRate)
+
 	return plugin, nil
 }
 
@@ -110,14 +195,98 @@ func (p *Plugin) Name() string {
 	return p.name
 }
 
-// Snapshot returns a snapshot of the current cluster metrics.
-func (p *Plugin) Snapshot() (interface{}, error) {
-	stats, err := GetStats(p.client)
+// Close stops the background processes of the client, if any.
+func (p *Plugin) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client != nil {
+		p.client.Stop()
+		p.client = nil
+	}
+	return nil
+}
+
+// getClient returns the client, creating it on first use. The client
+// is not created in NewPlugin so that metronomed starts even if the
+// cluster is unreachable. After a failed attempt, creating the client
+// is retried with exponential backoff, returning the last error until
+// then.
+func (p *Plugin) getClient() (*elastic.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client != nil {
+		return p.client, nil
+	}
+	if p.clientErr != nil && time.Now().Before(p.nextClient) {
+		return nil, p.clientErr
+	}
+	client, err := elastic.NewClient(p.options...)
 	if err != nil {
+		if p.clientBackoff == 0 {
+			p.clientBackoff = minClientBackoff
+		} else {
+			p.clientBackoff *= 2
+			if p.clientBackoff > maxClientBackoff {
+				p.clientBackoff = maxClientBackoff
+			}
+		}
+		p.clientErr = err
+		p.nextClient = time.Now().Add(p.clientBackoff)
 		return nil, err
 	}
+	p.client = client
+	p.clientErr = nil
+	p.clientBackoff = 0
+	return p.client, nil
+}
+
+// Snapshot returns a snapshot of the current cluster metrics, including
+// the per-node and per-index stats if configured. If the cluster is
+// unreachable, the health is reported as critical along with the error.
+// If only the per-node or per-index stats cannot be gathered, the other
+// metrics are reported with a health of at least warning.
+//
+// Rates and latencies are derived from the difference between two
+// snapshots, so the first snapshot reports zero rates.
+func (p *Plugin) Snapshot() (interface{}, error) {
+	client, err := p.getClient()
+	if err != nil {
+		return unhealthy(err), nil
+	}
+	stats, err := GetStats(client)
+	if err != nil {
+		return unhealthy(err), nil
+	}
+	var errs []string
+	var nodes map[string]*NodeStats
+	var watermarks map[string]Watermark
+	if len(p.nodes) > 0 {
+		nodes, err = GetNodeStats(client, p.nodes)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("cannot get node stats: %v", err))
+		}
+		watermarks, err = GetWatermarks(client)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("cannot get disk watermarks: %v", err))
+		}
+	}
+	var indices map[string]*IndexStats
+	if len(p.indices) > 0 {
+		indices, err = GetIndexStats(client, p.indices)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("cannot get index stats: %v", err))
+		}
+	}
+	now := time.Now()
+	secs := now.Sub(p.lastTime).Seconds()
+
+	prev := p.lastStats
+	if prev == nil {
+		prev, secs = stats, 0
+	}
 
 	// Update metrics
+	p.Status.Update(StatusCode(stats.Cluster.State))
 	p.NumNodes.Update(stats.NumNodes)
 	p.NumDataNodes.Update(stats.NumDataNodes)
 	p.Shards.Active.Update(stats.Shards.Active)
@@ -133,9 +302,29 @@ func (p *Plugin) Snapshot() (interface{}, error) {
 	p.OFDMin.Update(stats.OFDMin)
 	p.OFDMax.Update(stats.OFDMax)
 	p.OFDAvg.Update(stats.OFDAvg)
+	p.IndexingRate.Update(plugins.PerSec(plugins.DeltaInt(stats.IndexTotal, prev.IndexTotal), secs))
+	p.SearchRate.Update(plugins.PerSec(plugins.DeltaInt(stats.QueryTotal, prev.QueryTotal), secs))
+	p.MergeRate.Update(plugins.PerSec(plugins.DeltaInt(stats.MergeTotal, prev.MergeTotal), secs))
 
-	// Return data
-	return map[string]interface{}{
+	// The health of the plugin follows the cluster status
+	health := plugins.HealthUnknown
+	switch stats.Cluster.State {
+	case "green":
+		health = plugins.HealthOK
+	case "yellow":
+		health = plugins.HealthWarning
+	case "red":
+		health = plugins.HealthCritical
+	}
+	if len(errs) > 0 && health == plugins.HealthOK {
+		health = plugins.HealthWarning
+	}
+
+	data := map[string]interface{}{
+		"health":              health,
+		"cluster_name":        stats.Cluster.Name,
+		"status":              stats.Cluster.State,
+		"status_code":         p.Status.Value(),
 		"num_nodes":           p.NumNodes.Value(),
 		"num_data_nodes":      p.NumDataNodes.Value(),
 		"shards_active":       p.Shards.Active.Value(),
@@ -151,5 +340,157 @@ func (p *Plugin) Snapshot() (interface{}, error) {
 		"ofd_min":             p.OFDMin.Value(),
 		"ofd_max":             p.OFDMax.Value(),
 		"ofd_avg":             p.OFDAvg.Value(),
-	}, nil
+		"indexing_per_sec":    p.IndexingRate.Value(),
+		"search_per_sec":      p.SearchRate.Value(),
+		"merges_per_sec":      p.MergeRate.Value(),
+	}
+	if len(errs) > 0 {
+		data["error"] = strings.Join(errs, "; ")
+	}
+	// Series of nodes and indices are kept if their stats cannot be
+	// gathered, and unregistered once the node or index is gone.
+	if nodes != nil {
+		registered := make(map[string]bool)
+		nodeData := make(map[string]interface{})
+		for id, cur := range nodes {
+			if prev, found := p.lastNodes[id]; found {
+				nodeData[id] = p.node(cur, prev, secs, watermarks, registered)
+			} else {
+				nodeData[id] = p.node(cur, cur, 0, watermarks, registered)
+			}
+		}
+		data["nodes"] = nodeData
+		p.nodeSeries = unregisterGone(p.nodeSeries, registered)
+	}
+	if indices != nil {
+		registered := make(map[string]bool)
+		indexData := make(map[string]interface{})
+		for name, cur := range indices {
+			if prev, found := p.lastIndices[name]; found {
+				indexData[name] = p.index(cur, prev, secs, registered)
+			} else {
+				indexData[name] = p.index(cur, cur, 0, registered)
+			}
+		}
+		data["indices"] = indexData
+		p.indexSeries = unregisterGone(p.indexSeries, registered)
+	}
+	p.lastStats = stats
+	p.lastNodes = nodes
+	p.lastIndices = indices
+	p.lastTime = now
+
+	// Return data
+	return data, nil
+}
+
+// node computes the metrics of a node from two samples taken secs
+// seconds apart, and updates the registered metrics. The disk usage is
+// also reported in percent of each of the watermarks, so that 100
+// means the watermark is reached. The names of the metrics are added
+// to registered.
+func (p *Plugin) node(cur, prev *NodeStats, secs float64, watermarks map[string]Watermark, registered map[string]bool) map[string]interface{} {
+	prefix := fmt.Sprintf("elasticsearch.%s.nodes.%s.", p.name, cur.ID)
+
+	var rejected, prevRejected int64
+	rejectedData := make(map[string]interface{})
+	for pool, n := range cur.ThreadPoolRejected {
+		name := prefix + "thread_pool." + pool + ".rejected"
+		metrics.GetOrRegisterGauge(name, nil).Update(n)
+		registered[name] = true
+		rejectedData[pool] = n
+		rejected += n
+		prevRejected += prev.ThreadPoolRejected[pool]
+	}
+
+	var indexLatency, searchLatency float64
+	if n := plugins.DeltaInt(cur.IndexTotal, prev.IndexTotal); n > 0 {
+		indexLatency = plugins.DeltaInt(cur.IndexTimeInMillis, prev.IndexTimeInMillis) / n
+	}
+	if n := plugins.DeltaInt(cur.QueryTotal, prev.QueryTotal); n > 0 {
+		searchLatency = plugins.DeltaInt(cur.QueryTimeInMillis, prev.QueryTimeInMillis) / n
+	}
+
+	values := map[string]float64{
+		"heap_percent":                 cur.HeapPercent,
+		"gc_collections_per_sec":       plugins.PerSec(plugins.DeltaInt(cur.GCCount, prev.GCCount), secs),
+		"gc_time_ms_per_sec":           plugins.PerSec(plugins.DeltaInt(cur.GCTimeInMillis, prev.GCTimeInMillis), secs),
+		"thread_pool_rejected_per_sec": plugins.PerSec(plugins.DeltaInt(rejected, prevRejected), secs),
+		"disk_used_percent":            cur.DiskUsedPercent,
+		"index_latency_ms":             indexLatency,
+		"search_latency_ms":            searchLatency,
+	}
+	for level, w := range watermarks {
+		if threshold := w.Threshold(cur.DiskTotal); threshold > 0 {
+			values["disk_"+level+"_watermark_percent"] = cur.DiskUsedPercent / threshold * 100.0
+		}
+	}
+
+	data := map[string]interface{}{"name": cur.Name}
+	for key, value := range values {
+		metrics.GetOrRegisterGaugeFloat64(prefix+key, nil).Update(value)
+		registered[prefix+key] = true
+		data[key] = value
+	}
+	for key, value := range map[string]int64{
+		"heap_used":      cur.HeapUsed,
+		"heap_max":       cur.HeapMax,
+		"disk_available": cur.DiskAvailable,
+	} {
+		metrics.GetOrRegisterGauge(prefix+key, nil).Update(value)
+		registered[prefix+key] = true
+	}
+
+	data["heap_used"] = cur.HeapUsed
+	data["heap_max"] = cur.HeapMax
+	data["disk_total"] = cur.DiskTotal
+	data["disk_available"] = cur.DiskAvailable
+	data["thread_pool_rejected"] = rejectedData
+	return data
+}
+
+// index computes the metrics of an index from two samples taken secs
+// seconds apart, and updates the registered metrics. The names of the
+// metrics are added to registered.
+func (p *Plugin) index(cur, prev *IndexStats, secs float64, registered map[string]bool) map[string]interface{} {
+	prefix := fmt.Sprintf("elasticsearch.%s.indices.%s.", p.name, cur.Name)
+
+	indexingRate := plugins.PerSec(plugins.DeltaInt(cur.IndexTotal, prev.IndexTotal), secs)
+	searchRate := plugins.PerSec(plugins.DeltaInt(cur.QueryTotal, prev.QueryTotal), secs)
+
+	metrics.GetOrRegisterGauge(prefix+"docs_count", nil).Update(cur.DocsCount)
+	metrics.GetOrRegisterGauge(prefix+"docs_deleted", nil).Update(cur.DocsDeleted)
+	metrics.GetOrRegisterGauge(prefix+"store_size", nil).Update(cur.StoreSize)
+	metrics.GetOrRegisterGaugeFloat64(prefix+"indexing_per_sec", nil).Update(indexingRate)
+	metrics.GetOrRegisterGaugeFloat64(prefix+"search_per_sec", nil).Update(searchRate)
+	for _, key := range []string{"docs_count", "docs_deleted", "store_size", "indexing_per_sec", "search_per_sec"} {
+		registered[prefix+key] = true
+	}
+
+	return map[string]interface{}{
+		"docs_count":       cur.DocsCount,
+		"docs_deleted":     cur.DocsDeleted,
+		"store_size":       cur.StoreSize,
+		"indexing_per_sec": indexingRate,
+		"search_per_sec":   searchRate,
+	}
+}
+
+// unregisterGone unregisters the metrics named in prev that are missing
+// from cur, and returns cur.
+func unregisterGone(prev, cur map[string]bool) map[string]bool {
+	for name := range prev {
+		if !cur[name] {
+			metrics.Unregister(name)
+		}
+	}
+	return cur
+}
+
+// unhealthy returns the snapshot of a cluster that cannot be watched.
+func unhealthy(err error) map[string]interface{} {
+	return map[string]interface{}{
+		"health": plugins.HealthCritical,
+		"error":  err.Error(),
+	}
 }
@@ -4,7 +4,15 @@
 
 package elasticsearch
 
-import "github.com/olivere/elastic"
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/olivere/elastic"
+)
 
 // Stats contains all information gathered of a cluster periodically.
 type Stats struct {
@@ -34,6 +42,32 @@ type Stats struct {
 	OFDMin int64
 	OFDMax int64
 	OFDAvg int64
+
+	// Counters of all indices, cumulative
+	IndexTotal int64 // documents indexed (primary shards)
+	QueryTotal int64 // queries executed
+	MergeTotal int64 // merges performed
+}
+
+// Cluster status codes, for use in metrics and alert rules.
+const (
+	StatusGreen   = 0
+	StatusYellow  = 1
+	StatusRed     = 2
+	StatusUnknown = 3
+)
+
+// StatusCode returns the numeric code of a cluster status like "green".
+func StatusCode(status string) int64 {
+	switch status {
+	case "green":
+		return StatusGreen
+	case "yellow":
+		return StatusYellow
+	case "red":
+		return StatusRed
+	}
+	return StatusUnknown
 }
 
 // GetStats gathers a snapshot of the cluster.
@@ -47,6 +81,11 @@ func GetStats(client *elastic.Client) (*Stats, error) {
 		return nil, err
 	}
 
+	is, err := client.IndexStats().Metric("indexing", "search", "merge").Level("cluster").Do()
+	if err != nil {
+		return nil, err
+	}
+
 	stats := &Stats{}
 	stats.Cluster.Name = health.ClusterName
 	stats.Cluster.State = health.Status
@@ -73,5 +112,262 @@ func GetStats(client *elastic.Client) (*Stats, error) {
 	stats.OFDMax = cs.Nodes.Process.OpenFileDescriptors.Max
 	stats.OFDAvg = cs.Nodes.Process.OpenFileDescriptors.Avg
 
+	// Replicas index every document again, so count primaries only.
+	// Searches and merges run on both primaries and replicas.
+	if is.All != nil && is.All.Primaries != nil && is.All.Primaries.Indexing != nil {
+		stats.IndexTotal = is.All.Primaries.Indexing.IndexTotal
+	}
+	if is.All != nil && is.All.Total != nil {
+		if is.All.Total.Search != nil {
+			stats.QueryTotal = is.All.Total.Search.QueryTotal
+		}
+		if is.All.Total.Merges != nil {
+			stats.MergeTotal = is.All.Total.Merges.Total
+		}
+	}
+
 	return stats, nil
 }
+
+// NodeStats contains the counters and gauges of a single node.
+// Counters are cumulative since the start of the node.
+type NodeStats struct {
+	ID   string
+	Name string
+
+	HeapUsed    int64
+	HeapMax     int64
+	HeapPercent float64
+
+	GCCount        int64 // number of collections (all collectors)
+	GCTimeInMillis int64 // time spent in collections (all collectors)
+
+	ThreadPoolRejected map[string]int64 // rejected tasks by thread pool
+
+	DiskTotal       int64
+	DiskAvailable   int64
+	DiskUsedPercent float64 // percentage of disk used
+
+	IndexTotal        int64
+	IndexTimeInMillis int64
+	QueryTotal        int64
+	QueryTimeInMillis int64
+}
+
+// GetNodeStats gathers the stats of the nodes whose names match the
+// patterns, e.g. "es-data-*", keyed by node ID. Node names need not be
+// unique, and a restarted node may come back with a different name.
+func GetNodeStats(client *elastic.Client, patterns []string) (map[string]*NodeStats, error) {
+	res, err := client.NodesStats().
+		NodeId(patterns...).
+		Metric("jvm", "thread_pool", "fs", "indices").
+		IndexMetric("indexing", "search").
+		Do()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[string]*NodeStats)
+	for id, node := range res.Nodes {
+		s := &NodeStats{ID: id, Name: node.Name, ThreadPoolRejected: make(map[string]int64)}
+		if s.Name == "" {
+			s.Name = id
+		}
+		if jvm := node.JVM; jvm != nil {
+			if jvm.Mem != nil {
+				s.HeapUsed = jvm.Mem.HeapUsedInBytes
+				s.HeapMax = jvm.Mem.HeapMaxInBytes
+				if s.HeapMax > 0 {
+					s.HeapPercent = float64(100*s.HeapUsed) / float64(s.HeapMax)
+				}
+			}
+			if jvm.GC != nil {
+				for _, c := range jvm.GC.Collectors {
+					s.GCCount += c.CollectionCount
+					s.GCTimeInMillis += c.CollectionTimeInMillis
+				}
+			}
+		}
+		for pool, tp := range node.ThreadPool {
+			s.ThreadPoolRejected[pool] = tp.Rejected
+		}
+		if node.FS != nil && node.FS.Total != nil {
+			s.DiskTotal = node.FS.Total.TotalInBytes
+			s.DiskAvailable = node.FS.Total.AvailableInBytes
+			if s.DiskTotal > 0 {
+				s.DiskUsedPercent = float64(100*(s.DiskTotal-s.DiskAvailable)) / float64(s.DiskTotal)
+			}
+		}
+		if indices := node.Indices; indices != nil {
+			if indices.Indexing != nil {
+				s.IndexTotal = indices.Indexing.IndexTotal
+				s.IndexTimeInMillis = indices.Indexing.IndexTimeInMillis
+			}
+			if indices.Search != nil {
+				s.QueryTotal = indices.Search.QueryTotal
+				s.QueryTimeInMillis = indices.Search.QueryTimeInMillis
+			}
+		}
+		nodes[id] = s
+	}
+	return nodes, nil
+}
+
+// WatermarkLevels are the levels of the disk watermarks of a cluster.
+// Elasticsearch stops allocating shards to a node above the low
+// watermark, moves shards away above the high watermark, and blocks
+// writes to its indices above the flood stage watermark.
+var WatermarkLevels = []string{"low", "high", "flood_stage"}
+
+// defaultWatermarks are the watermarks that apply unless configured.
+var defaultWatermarks = map[string]string{
+	"low":         "85%",
+	"high":        "90%",
+	"flood_stage": "95%",
+}
+
+// Watermark is a disk watermark, given either as the percentage of the
+// disk used or as the free disk space left.
+type Watermark struct {
+	UsedPercent float64 // e.g. 85 for "85%" or "0.85"
+	FreeBytes   int64   // e.g. 524288000 for "500mb"
+}
+
+// Threshold returns the percentage of a disk of total bytes that is
+// used when the watermark is reached.
+func (w Watermark) Threshold(total int64) float64 {
+	if w.FreeBytes > 0 {
+		if total <= 0 {
+			return 0
+		}
+		return float64(100*(total-w.FreeBytes)) / float64(total)
+	}
+	return w.UsedPercent
+}
+
+// GetWatermarks gathers the disk watermarks of the cluster, keyed by
+// level (see WatermarkLevels). Transient settings take precedence over
+// persistent ones, and unset watermarks have their default value.
+func GetWatermarks(client *elastic.Client) (map[string]Watermark, error) {
+	params := url.Values{}
+	params.Set("flat_settings", "true")
+	params.Set("include_defaults", "true")
+	res, err := client.PerformRequest("GET", "/_cluster/settings", params, nil)
+	if err != nil {
+		return nil, err
+	}
+	var settings struct {
+		Persistent map[string]interface{} `json:"persistent"`
+		Transient  map[string]interface{} `json:"transient"`
+		Defaults   map[string]interface{} `json:"defaults"`
+	}
+	if err := json.Unmarshal(res.Body, &settings); err != nil {
+		return nil, err
+	}
+
+	watermarks := make(map[string]Watermark)
+	for _, level := range WatermarkLevels {
+		key := "cluster.routing.allocation.disk.watermark." + level
+		value := defaultWatermarks[level]
+		for _, m := range []map[string]interface{}{settings.Defaults, settings.Persistent, settings.Transient} {
+			if v, ok := m[key].(string); ok {
+				value = v
+			}
+		}
+		w, err := ParseWatermark(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", key, err)
+		}
+		watermarks[level] = w
+	}
+	return watermarks, nil
+}
+
+// byteUnits are the units of byte sizes in Elasticsearch settings.
+// Longer units come first so that e.g. "kb" is not taken for "b".
+var byteUnits = []struct {
+	suffix string
+	size   float64
+}{
+	{"pb", 1 << 50}, {"tb", 1 << 40}, {"gb", 1 << 30}, {"mb", 1 << 20}, {"kb", 1 << 10},
+	{"p", 1 << 50}, {"t", 1 << 40}, {"g", 1 << 30}, {"m", 1 << 20}, {"k", 1 << 10},
+	{"b", 1},
+}
+
+// ParseWatermark parses a disk watermark setting like "85%", a ratio
+// like "0.85", or a byte size of free disk space like "500mb".
+func ParseWatermark(value string) (Watermark, error) {
+	s := strings.ToLower(strings.TrimSpace(value))
+	if strings.HasSuffix(s, "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return Watermark{}, fmt.Errorf("invalid percentage %q", value)
+		}
+		return Watermark{UsedPercent: percent}, nil
+	}
+	if ratio, err := strconv.ParseFloat(s, 64); err == nil {
+		if ratio < 0 || ratio > 1 {
+			return Watermark{}, fmt.Errorf("invalid ratio %q", value)
+		}
+		return Watermark{UsedPercent: ratio * 100}, nil
+	}
+	for _, unit := range byteUnits {
+		if !strings.HasSuffix(s, unit.suffix) {
+			continue
+		}
+		n, err := strconv.ParseFloat(strings.TrimSuffix(s, unit.suffix), 64)
+		if err != nil || n < 0 {
+			return Watermark{}, fmt.Errorf("invalid byte size %q", value)
+		}
+		return Watermark{FreeBytes: int64(n * unit.size)}, nil
+	}
+	return Watermark{}, fmt.Errorf("invalid watermark %q", value)
+}
+
+// IndexStats contains the counters and gauges of a single index.
+// Counters are cumulative since the index was opened.
+type IndexStats struct {
+	Name string
+
+	DocsCount   int64 // documents in primary shards
+	DocsDeleted int64 // deleted documents in primary shards
+	StoreSize   int64 // size of all shards, including replicas
+
+	IndexTotal int64 // documents indexed (primary shards)
+	QueryTotal int64 // queries executed (all shards)
+}
+
+// GetIndexStats gathers the stats of the indices whose names match the
+// patterns, e.g. "logstash-*", keyed by index name.
+func GetIndexStats(client *elastic.Client, patterns []string) (map[string]*IndexStats, error) {
+	res, err := client.IndexStats(patterns...).
+		Metric("docs", "store", "indexing", "search").
+		Do()
+	if err != nil {
+		return nil, err
+	}
+
+	indices := make(map[string]*IndexStats)
+	for name, index := range res.Indices {
+		s := &IndexStats{Name: name}
+		if p := index.Primaries; p != nil {
+			if p.Docs != nil {
+				s.DocsCount = p.Docs.Count
+				s.DocsDeleted = p.Docs.Deleted
+			}
+			if p.Indexing != nil {
+				s.IndexTotal = p.Indexing.IndexTotal
+			}
+		}
+		if t := index.Total; t != nil {
+			if t.Store != nil {
+				s.StoreSize = t.Store.SizeInBytes
+			}
+			if t.Search != nil {
+				s.QueryTotal = t.Search.QueryTotal
+			}
+		}
+		indices[name] = s
+	}
+	return indices, nil
+}
@@ -0,0 +1,341 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package elasticsearch
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/olivere/elastic"
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+const (
+	healthJSON = `{"cluster_name":"test","status":"yellow","number_of_nodes":3,"number_of_data_nodes":2,
+"active_shards":10,"relocating_shards":1,"initializing_shards":2,"unassigned_shards":3,"number_of_pending_tasks":4}`
+
+	clusterStatsJSON = `{"cluster_name":"test","indices":{"count":5},
+"nodes":{"jvm":{"mem":{"heap_used_in_bytes":1024,"heap_max_in_bytes":4096}},
+"process":{"cpu":{"percent":12},"open_file_descriptors":{"min":100,"max":300,"avg":200}}}}`
+
+	clusterIndexStatsJSON = `{"_all":{"primaries":{"indexing":{"index_total":1000}},
+"total":{"indexing":{"index_total":2000},"search":{"query_total":500},"merges":{"total":7}}}}`
+
+	settingsJSON = `{"persistent":{"cluster.routing.allocation.disk.watermark.high":"500mb"},
+"transient":{"cluster.routing.allocation.disk.watermark.low":"0.8"},
+"defaults":{"cluster.routing.allocation.disk.watermark.low":"85%",
+"cluster.routing.allocation.disk.watermark.high":"90%",
+"cluster.routing.allocation.disk.watermark.flood_stage":"95%"}}`
+
+	node1JSON = `"abc123":{"name":"es-data-1",
+"jvm":{"mem":{"heap_used_in_bytes":512,"heap_max_in_bytes":1024},
+"gc":{"collectors":{"young":{"collection_count":10,"collection_time_in_millis":100},
+"old":{"collection_count":1,"collection_time_in_millis":50}}}},
+"thread_pool":{"search":{"rejected":3},"write":{"rejected":1}},
+"fs":{"total":{"total_in_bytes":107374182400,"available_in_bytes":21474836480}},
+"indices":{"indexing":{"index_total":1000,"index_time_in_millis":2000},
+"search":{"query_total":500,"query_time_in_millis":250}}}`
+
+	node2JSON = `"def456":{"name":"es-data-2",
+"thread_pool":{"search":{"rejected":0}},
+"fs":{"total":{"total_in_bytes":1000,"available_in_bytes":500}}}`
+
+	index1JSON = `"logstash-1":{"primaries":{"docs":{"count":100,"deleted":2},"indexing":{"index_total":100}},
+"total":{"store":{"size_in_bytes":2048},"search":{"query_total":50}}}`
+
+	index2JSON = `"logstash-2":{"primaries":{"docs":{"count":10}},"total":{"store":{"size_in_bytes":512}}}`
+)
+
+// cluster is a fake Elasticsearch cluster whose nodes and indices can
+// be changed between requests.
+type cluster struct {
+	mu      sync.Mutex
+	nodes   []string
+	indices []string
+}
+
+func (c *cluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	switch path := r.URL.Path; {
+	case strings.HasPrefix(path, "/_cluster/health"):
+		fmt.Fprint(w, healthJSON)
+	case path == "/_cluster/stats":
+		fmt.Fprint(w, clusterStatsJSON)
+	case path == "/_cluster/settings":
+		fmt.Fprint(w, settingsJSON)
+	case strings.HasPrefix(path, "/_stats/"):
+		fmt.Fprint(w, clusterIndexStatsJSON)
+	case strings.HasPrefix(path, "/_nodes/"):
+		fmt.Fprintf(w, `{"cluster_name":"test","nodes":{%s}}`, strings.Join(c.nodes, ","))
+	case strings.Contains(path, "/_stats/"):
+		fmt.Fprintf(w, `{"indices":{%s}}`, strings.Join(c.indices, ","))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (c *cluster) set(nodes, indices []string) {
+	c.mu.Lock()
+	c.nodes, c.indices = nodes, indices
+	c.mu.Unlock()
+}
+
+func newTestCluster(t *testing.T) (*httptest.Server, *cluster, *elastic.Client) {
+	c := &cluster{nodes: []string{node1JSON, node2JSON}, indices: []string{index1JSON, index2JSON}}
+	srv := httptest.NewServer(c)
+	client, err := elastic.NewClient(elastic.SetURL(srv.URL), elastic.SetSniff(false), elastic.SetHealthcheck(false))
+	if err != nil {
+		srv.Close()
+		t.Fatal(err)
+	}
+	return srv, c, client
+}
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestGetStats(t *testing.T) {
+	srv, _, client := newTestCluster(t)
+	defer srv.Close()
+
+	stats, err := GetStats(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &Stats{
+		NumNodes:        3,
+		NumDataNodes:    2,
+		NumPendingTasks: 4,
+		NumIndices:      5,
+		HeapUsed:        1024,
+		HeapMax:         4096,
+		HeapPercent:     25,
+		CPUPercent:      12,
+		OFDMin:          100,
+		OFDMax:          300,
+		OFDAvg:          200,
+		IndexTotal:      1000, // primaries only
+		QueryTotal:      500,
+		MergeTotal:      7,
+	}
+	expected.Cluster.Name = "test"
+	expected.Cluster.State = "yellow"
+	expected.Shards.Active = 10
+	expected.Shards.Relocating = 1
+	expected.Shards.Initializing = 2
+	expected.Shards.Unassigned = 3
+	if *stats != *expected {
+		t.Errorf("expected\n%+v\ngot\n%+v", *expected, *stats)
+	}
+}
+
+func TestGetNodeStats(t *testing.T) {
+	srv, _, client := newTestCluster(t)
+	defer srv.Close()
+
+	nodes, err := GetNodeStats(client, []string{"es-*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 {
+		t.Fatalf("expected %d nodes; got %d", 2, len(nodes))
+	}
+	s := nodes["abc123"]
+	if s == nil {
+		t.Fatalf("expected nodes keyed by ID; got %v", nodes)
+	}
+	if s.ID != "abc123" || s.Name != "es-data-1" {
+		t.Errorf("expected node abc123 named es-data-1; got %s named %s", s.ID, s.Name)
+	}
+	if s.HeapUsed != 512 || s.HeapMax != 1024 || s.HeapPercent != 50 {
+		t.Errorf("expected heap of 512/1024 (50%%); got %d/%d (%v%%)", s.HeapUsed, s.HeapMax, s.HeapPercent)
+	}
+	if s.GCCount != 11 || s.GCTimeInMillis != 150 {
+		t.Errorf("expected GC count and time summed over collectors 11/150; got %d/%d", s.GCCount, s.GCTimeInMillis)
+	}
+	if s.ThreadPoolRejected["search"] != 3 || s.ThreadPoolRejected["write"] != 1 {
+		t.Errorf("expected rejected search=3 and write=1; got %v", s.ThreadPoolRejected)
+	}
+	if s.DiskTotal != 107374182400 || s.DiskAvailable != 21474836480 || !approx(s.DiskUsedPercent, 80) {
+		t.Errorf("expected disk of 100GB with 20GB available (80%% used); got %d/%d (%v%%)", s.DiskTotal, s.DiskAvailable, s.DiskUsedPercent)
+	}
+	if s.IndexTotal != 1000 || s.IndexTimeInMillis != 2000 || s.QueryTotal != 500 || s.QueryTimeInMillis != 250 {
+		t.Errorf("expected indexing 1000/2000ms and search 500/250ms; got %+v", s)
+	}
+}
+
+func TestGetIndexStats(t *testing.T) {
+	srv, _, client := newTestCluster(t)
+	defer srv.Close()
+
+	indices, err := GetIndexStats(client, []string{"logstash-*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := IndexStats{
+		Name:        "logstash-1",
+		DocsCount:   100,
+		DocsDeleted: 2,
+		StoreSize:   2048,
+		IndexTotal:  100,
+		QueryTotal:  50,
+	}
+	if s := indices["logstash-1"]; s == nil || *s != expected {
+		t.Errorf("expected %+v; got %+v", expected, s)
+	}
+	if s := indices["logstash-2"]; s == nil || s.DocsCount != 10 || s.StoreSize != 512 {
+		t.Errorf("expected logstash-2 with 10 docs of 512 bytes; got %+v", s)
+	}
+}
+
+func TestGetWatermarks(t *testing.T) {
+	srv, _, client := newTestCluster(t)
+	defer srv.Close()
+
+	watermarks, err := GetWatermarks(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]Watermark{
+		"low":         {UsedPercent: 80},              // transient
+		"high":        {FreeBytes: 500 * 1024 * 1024}, // persistent
+		"flood_stage": {UsedPercent: 95},              // default
+	}
+	for level, w := range expected {
+		if got := watermarks[level]; !approx(got.UsedPercent, w.UsedPercent) || got.FreeBytes != w.FreeBytes {
+			t.Errorf("%s: expected %+v; got %+v", level, w, got)
+		}
+	}
+}
+
+func TestParseWatermark(t *testing.T) {
+	tests := []struct {
+		Value    string
+		Expected Watermark
+	}{
+		{"85%", Watermark{UsedPercent: 85}},
+		{"87.5%", Watermark{UsedPercent: 87.5}},
+		{"0.9", Watermark{UsedPercent: 90}},
+		{"1", Watermark{UsedPercent: 100}},
+		{"500mb", Watermark{FreeBytes: 500 << 20}},
+		{"1.5GB", Watermark{FreeBytes: 3 << 29}},
+		{"10g", Watermark{FreeBytes: 10 << 30}},
+		{"100kb", Watermark{FreeBytes: 100 << 10}},
+		{"2048b", Watermark{FreeBytes: 2048}},
+	}
+	for _, test := range tests {
+		w, err := ParseWatermark(test.Value)
+		if err != nil {
+			t.Errorf("%s: %v", test.Value, err)
+			continue
+		}
+		if !approx(w.UsedPercent, test.Expected.UsedPercent) || w.FreeBytes != test.Expected.FreeBytes {
+			t.Errorf("%s: expected %+v; got %+v", test.Value, test.Expected, w)
+		}
+	}
+
+	for _, value := range []string{"", "101%", "1.5", "-1", "lots", "10xb"} {
+		if _, err := ParseWatermark(value); err == nil {
+			t.Errorf("expected error for %q", value)
+		}
+	}
+}
+
+func TestWatermarkThreshold(t *testing.T) {
+	if got := (Watermark{UsedPercent: 85}).Threshold(1000); got != 85 {
+		t.Errorf("expected %v; got %v", 85, got)
+	}
+	if got := (Watermark{FreeBytes: 100}).Threshold(1000); got != 90 {
+		t.Errorf("expected %v; got %v", 90, got)
+	}
+	if got := (Watermark{FreeBytes: 100}).Threshold(0); got != 0 {
+		t.Errorf("expected %v; got %v", 0, got)
+	}
+}
+
+func TestSnapshotUnregistersNodesAndIndices(t *testing.T) {
+	srv, c, _ := newTestCluster(t)
+	defer srv.Close()
+
+	disabled := false
+	p, err := NewPlugin("unregister", &Config{
+		Urls:        []string{srv.URL},
+		Sniff:       &disabled,
+		Healthcheck: &disabled,
+		Nodes:       []string{"es-*"},
+		Indices:     []string{"logstash-*"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	snapshot, err := p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := snapshot.(map[string]interface{})
+	if _, found := data["error"]; found {
+		t.Fatalf("expected no error; got %v", data["error"])
+	}
+	node := data["nodes"].(map[string]interface{})["abc123"].(map[string]interface{})
+	if got := node["name"]; got != "es-data-1" {
+		t.Errorf("expected name %q; got %v", "es-data-1", got)
+	}
+	// 80% of the disk is used, so the low watermark of 80% is reached
+	if got := node["disk_low_watermark_percent"].(float64); !approx(got, 100) {
+		t.Errorf("expected %v; got %v", 100, got)
+	}
+	if got := node["disk_flood_stage_watermark_percent"].(float64); !approx(got, 80.0/95.0*100.0) {
+		t.Errorf("expected %v; got %v", 80.0/95.0*100.0, got)
+	}
+	threshold := float64(100*(107374182400-500*1024*1024)) / 107374182400
+	if got := node["disk_high_watermark_percent"].(float64); !approx(got, 80/threshold*100) {
+		t.Errorf("expected %v; got %v", 80/threshold*100, got)
+	}
+
+	gone := []string{
+		"elasticsearch.unregister.nodes.def456.heap_used",
+		"elasticsearch.unregister.nodes.def456.disk_used_percent",
+		"elasticsearch.unregister.nodes.def456.thread_pool.search.rejected",
+		"elasticsearch.unregister.indices.logstash-2.docs_count",
+		"elasticsearch.unregister.indices.logstash-2.search_per_sec",
+	}
+	kept := []string{
+		"elasticsearch.unregister.nodes.abc123.heap_used",
+		"elasticsearch.unregister.nodes.abc123.disk_low_watermark_percent",
+		"elasticsearch.unregister.nodes.abc123.thread_pool.write.rejected",
+		"elasticsearch.unregister.indices.logstash-1.docs_count",
+	}
+	for _, name := range append(gone, kept...) {
+		if metrics.Get(name) == nil {
+			t.Errorf("expected %s to be registered", name)
+		}
+	}
+
+	// Node def456 leaves the cluster and index logstash-2 is deleted
+	c.set([]string{node1JSON}, []string{index1JSON})
+	if _, err := p.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range gone {
+		if metrics.Get(name) != nil {
+			t.Errorf("expected %s to be unregistered", name)
+		}
+	}
+	for _, name := range kept {
+		if metrics.Get(name) == nil {
+			t.Errorf("expected %s to be registered", name)
+		}
+	}
+}
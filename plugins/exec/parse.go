@@ -0,0 +1,181 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package exec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Output formats understood by the plugin.
+const (
+	FormatAuto     = ""
+	FormatJSON     = "json"
+	FormatKeyValue = "keyvalue"
+	FormatNagios   = "nagios"
+)
+
+var (
+	nagiosStatusPattern = regexp.MustCompile(`^[\w\s\-]*\b(OK|WARNING|CRITICAL|UNKNOWN)(\s*[-:]|$)`)
+	perfdataPattern     = regexp.MustCompile(`('[^']+'|[^\s=']+)=([^\s]+)`)
+)
+
+// Parse parses the output of a command in the given format into a map.
+// If format is FormatAuto, the format is guessed from the output.
+func Parse(format string, out []byte) (map[string]interface{}, error) {
+	if format == FormatAuto {
+		format = detectFormat(out)
+	}
+	switch format {
+	case FormatJSON:
+		return parseJSON(out)
+	case FormatKeyValue:
+		return parseKeyValue(out)
+	case FormatNagios:
+		return parseNagios(out)
+	}
+	return nil, fmt.Errorf("unknown output format %q", format)
+}
+
+// detectFormat guesses the format of the output.
+func detectFormat(out []byte) string {
+	out = bytes.TrimSpace(out)
+	if len(out) > 0 && out[0] == '{' {
+		return FormatJSON
+	}
+	firstLine := out
+	if i := bytes.IndexByte(out, '\n'); i >= 0 {
+		firstLine = out[:i]
+	}
+	if bytes.IndexByte(firstLine, '|') >= 0 || nagiosStatusPattern.Match(firstLine) {
+		return FormatNagios
+	}
+	return FormatKeyValue
+}
+
+// parseJSON parses a JSON object.
+func parseJSON(out []byte) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	if err := json.Unmarshal(out, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// parseKeyValue parses lines of the form "key=value". Empty lines and
+// lines starting with # are skipped.
+func parseKeyValue(out []byte) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid line %q", line)
+		}
+		key := strings.TrimSpace(kv[0])
+		if key == "" {
+			return nil, fmt.Errorf("invalid line %q", line)
+		}
+		values[key] = parseValue(strings.TrimSpace(kv[1]))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// parseNagios parses the output of a Nagios plugin, i.e. a status text
+// and optional performance data separated by a pipe character. The
+// performance data may continue on subsequent lines after another pipe.
+// See https://nagios-plugins.org/doc/guidelines.html#AEN200 for details.
+func parseNagios(out []byte) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	var perfdata []string
+	inPerfdata := false
+	for i, line := range lines {
+		if inPerfdata {
+			perfdata = append(perfdata, line)
+			continue
+		}
+		text := line
+		if j := strings.Index(line, "|"); j >= 0 {
+			text = line[:j]
+			perfdata = append(perfdata, line[j+1:])
+			// A pipe in the long text starts multi-line performance data.
+			inPerfdata = i > 0
+		}
+		if i == 0 {
+			values["output"] = strings.TrimSpace(text)
+		}
+	}
+
+	for _, pd := range perfdata {
+		for _, m := range perfdataPattern.FindAllStringSubmatch(pd, -1) {
+			label := strings.Trim(m[1], "'")
+			// Value is the first field of value[UOM];[warn];[crit];[min];[max]
+			fields := strings.Split(m[2], ";")
+			v, ok := parsePerfValue(fields[0])
+			if !ok {
+				continue
+			}
+			values[label] = v
+		}
+	}
+	return values, nil
+}
+
+// parsePerfValue parses a Nagios performance value with an optional unit
+// of measurement. Times are converted to seconds and sizes to bytes.
+func parsePerfValue(s string) (float64, bool) {
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '-' && r != '+'
+	})
+	num, uom := s, ""
+	if i >= 0 {
+		num, uom = s[:i], s[i:]
+	}
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, false
+	}
+	switch strings.ToUpper(uom) {
+	case "MS":
+		v /= 1000
+	case "US":
+		v /= 1000 * 1000
+	case "KB":
+		v *= 1024
+	case "MB":
+		v *= 1024 * 1024
+	case "GB":
+		v *= 1024 * 1024 * 1024
+	case "TB":
+		v *= 1024 * 1024 * 1024 * 1024
+	}
+	return v, true
+}
+
+// parseValue returns s as an int64 or float64 if it is numeric, and as
+// a string otherwise.
+func parseValue(s string) interface{} {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return strings.Trim(s, `"'`)
+}
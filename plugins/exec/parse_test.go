@@ -0,0 +1,136 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package exec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		Format string
+		Output string
+		Want   map[string]interface{}
+	}{
+		// JSON
+		{
+			Format: FormatJSON,
+			Output: `{"queue_length": 12, "state": "running"}`,
+			Want:   map[string]interface{}{"queue_length": 12.0, "state": "running"},
+		},
+		// key=value
+		{
+			Format: FormatKeyValue,
+			Output: "# queue stats\nqueue_length=12\n\nload = 0.5\nstate=\"running\"\n",
+			Want:   map[string]interface{}{"queue_length": int64(12), "load": 0.5, "state": "running"},
+		},
+		// Nagios without performance data
+		{
+			Format: FormatNagios,
+			Output: "DISK OK - free space: / 3326 MB (56%);\n",
+			Want:   map[string]interface{}{"output": "DISK OK - free space: / 3326 MB (56%);"},
+		},
+		// Nagios with performance data and units
+		{
+			Format: FormatNagios,
+			Output: "PING OK - Packet loss = 0%, RTA = 0.80 ms | rta=0.80ms;100.0;500.0;0.0 pl=0%;20;60;;\n",
+			Want:   map[string]interface{}{"output": "PING OK - Packet loss = 0%, RTA = 0.80 ms", "rta": 0.0008, "pl": 0.0},
+		},
+		// Nagios with quoted labels, sizes and multi-line performance data
+		{
+			Format: FormatNagios,
+			Output: "DISK OK - free space: / 3326 MB | '/ free'=3326MB;;;0;7000\n" +
+				"/ 15272 MB (77%);\n" +
+				"/boot 68 MB (69%); | /boot=68KB;88;93;0;98\n" +
+				"/home=69357B;15337;10000;0;\n",
+			Want: map[string]interface{}{
+				"output": "DISK OK - free space: / 3326 MB",
+				"/ free": 3326.0 * 1024 * 1024,
+				"/boot":  68.0 * 1024,
+				"/home":  69357.0,
+			},
+		},
+		// Invalid performance values are skipped
+		{
+			Format: FormatNagios,
+			Output: "OK | time=0.5s size=abc\n",
+			Want:   map[string]interface{}{"output": "OK", "time": 0.5},
+		},
+		// Detected formats
+		{
+			Output: "  {\"up\": 1}",
+			Want:   map[string]interface{}{"up": 1.0},
+		},
+		{
+			Output: "up=1\n",
+			Want:   map[string]interface{}{"up": int64(1)},
+		},
+		{
+			Output: "CRITICAL - service is down\n",
+			Want:   map[string]interface{}{"output": "CRITICAL - service is down"},
+		},
+		{
+			Output: "Service is up | uptime=3600s\n",
+			Want:   map[string]interface{}{"output": "Service is up", "uptime": 3600.0},
+		},
+	}
+	for i, test := range tests {
+		got, err := Parse(test.Format, []byte(test.Output))
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.Want) {
+			t.Errorf("#%d: expected %v; got %v", i, test.Want, got)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		Format string
+		Output string
+	}{
+		{FormatJSON, `{"up": `},
+		{FormatJSON, `[1, 2]`},
+		{FormatKeyValue, "up=1\nno value\n"},
+		{FormatKeyValue, "=1\n"},
+		{"xml", "<up>1</up>"},
+	}
+	for _, test := range tests {
+		if _, err := Parse(test.Format, []byte(test.Output)); err == nil {
+			t.Errorf("expected error for %q in format %q", test.Output, test.Format)
+		}
+	}
+}
+
+func TestParsePerfValue(t *testing.T) {
+	tests := []struct {
+		Input string
+		Want  float64
+		OK    bool
+	}{
+		{"12", 12, true},
+		{"-1.5", -1.5, true},
+		{"10%", 10, true},
+		{"3s", 3, true},
+		{"250ms", 0.25, true},
+		{"500us", 0.0005, true},
+		{"2KB", 2048, true},
+		{"1MB", 1024 * 1024, true},
+		{"1GB", 1024 * 1024 * 1024, true},
+		{"1TB", 1024 * 1024 * 1024 * 1024, true},
+		{"12c", 12, true},
+		{"U", 0, false},
+		{"", 0, false},
+	}
+	for _, test := range tests {
+		got, ok := parsePerfValue(test.Input)
+		if ok != test.OK || got != test.Want {
+			t.Errorf("%q: expected %v, %v; got %v, %v", test.Input, test.Want, test.OK, got, ok)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package exec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+var (
+	defaultTimeout = 10 * time.Second
+
+	// waitDelay is how long to wait for the output of the command after
+	// it has been killed, e.g. because a child process it forked still
+	// holds stdout open.
+	waitDelay = 1 * time.Second
+
+	// maxKeys is the maximum number of keys of the output that are
+	// registered as metrics, so that commands with changing keys do
+	// not grow the registry without bounds.
+	maxKeys = 100
+
+	// reservedKeys are set by the plugin itself. Keys of the output
+	// with the same name are reported with the prefix "output_".
+	reservedKeys = map[string]bool{
+		"health":    true,
+		"exit_code": true,
+		"duration":  true,
+		"error":     true,
+	}
+)
+
+// Config is the configuration for the exec plugin.
+type Config struct {
+	// Command to run.
+	Command string
+	// Args to pass to the command.
+	Args []string
+	// Timeout after which the command gets killed (default: 10s).
+	Timeout time.Duration
+	// Interval between two runs of the command. If zero, a new run
+	// starts on every snapshot unless the previous run is still going,
+	// otherwise the last result is reused until the interval has passed.
+	Interval time.Duration
+	// Format of the output of the command, i.e. FormatJSON,
+	// FormatKeyValue or FormatNagios. If empty, it is detected from
+	// the output.
+	Format string
+}
+
+// Plugin runs an external command and reports its output.
+type Plugin struct {
+	name     string
+	command  string
+	args     []string
+	timeout  time.Duration
+	interval time.Duration
+	format   string
+
+	mu       sync.Mutex // guards the fields below
+	running  bool
+	lastRun  time.Time
+	snapshot map[string]interface{}
+
+	keys map[string]bool // keys registered as metrics, used by run only
+
+	ExitCode metrics.Gauge        // exit code of the last run
+	Duration metrics.GaugeFloat64 // duration of the last run in seconds
+}
+
+// NewPlugin initializes a new plugin that runs an external command.
+// Pass a name to differentiate between different commands.
+func NewPlugin(name string, config *Config) (*Plugin, error) {
+	if name == "" {
+		return nil, errors.New("no name specified")
+	}
+	if config == nil {
+		return nil, errors.New("no configuration specified")
+	}
+	if config.Command == "" {
+		return nil, errors.New("no command specified")
+	}
+	switch config.Format {
+	case FormatAuto, FormatJSON, FormatKeyValue, FormatNagios:
+	default:
+		return nil, fmt.Errorf("unknown output format %q", config.Format)
+	}
+
+	plugin := &Plugin{
+		name:     name,
+		command:  config.Command,
+		args:     config.Args,
+		timeout:  config.Timeout,
+		interval: config.Interval,
+		format:   config.Format,
+		keys:     make(map[string]bool),
+	}
+	if plugin.timeout <= 0 {
+		plugin.timeout = defaultTimeout
+	}
+
+	plugin.ExitCode = metrics.NewGauge()
+	metrics.Register(fmt.Sprintf("exec.%s.exit_code", plugin.name), plugin.ExitCode)
+	plugin.Duration = metrics.NewGaugeFloat64()
+	metrics.Register(fmt.Sprintf("exec.%s.duration", plugin.name), plugin.Duration)
+
+	return plugin, nil
+}
+
+// Name of the plugin. It is prefixed with "exec." so that instances
+// do not collide with other plugins of the same name.
+func (p *Plugin) Name() string {
+	return "exec." + p.name
+}
+
+// Snapshot returns the parsed output of the last run of the command.
+// The command runs in the background, so that a slow command does not
+// hold up the snapshots of other plugins. A new run starts once the
+// interval since the last run has passed and the previous run has
+// finished. Until the first run has finished, the health is unknown.
+func (p *Plugin) Snapshot() (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.running && (p.snapshot == nil || time.Since(p.lastRun) >= p.interval) {
+		p.running = true
+		p.lastRun = time.Now()
+		go func() {
+			data := p.run()
+			p.mu.Lock()
+			p.snapshot = data
+			p.running = false
+			p.mu.Unlock()
+		}()
+	}
+	if p.snapshot == nil {
+		return map[string]interface{}{"health": plugins.HealthUnknown}, nil
+	}
+	return p.snapshot, nil
+}
+
+// run runs the command and returns its parsed output together with the
+// health derived from the exit code, Nagios-style: 0 is ok, 1 is warning,
+// 2 is critical, everything else is unknown.
+func (p *Plugin) run() map[string]interface{} {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.command, p.args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = waitDelay
+
+	start := time.Now()
+	err := cmd.Run()
+	duration := time.Since(start)
+
+	exitCode := 0
+	var errmsg string
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && ctx.Err() == nil {
+			exitCode = exitErr.ExitCode()
+		} else {
+			exitCode = -1
+			errmsg = err.Error()
+			if ctx.Err() == context.DeadlineExceeded {
+				errmsg = fmt.Sprintf("timed out after %v", p.timeout)
+			}
+		}
+	}
+	if s := strings.TrimSpace(stderr.String()); s != "" {
+		if errmsg != "" {
+			errmsg += ": "
+		}
+		errmsg += s
+	}
+
+	// Update metrics
+	p.ExitCode.Update(int64(exitCode))
+	p.Duration.Update(duration.Seconds())
+
+	data, perr := Parse(p.format, stdout.Bytes())
+	if perr != nil {
+		data = make(map[string]interface{})
+		if exitCode == 0 {
+			exitCode = -1
+		}
+		if errmsg != "" {
+			errmsg += ": "
+		}
+		errmsg += fmt.Sprintf("cannot parse output: %v", perr)
+	}
+	for key := range reservedKeys {
+		if value, found := data[key]; found {
+			delete(data, key)
+			data["output_"+key] = value
+		}
+	}
+	var dropped int
+	for key, value := range data {
+		f, ok := toFloat64(value)
+		if !ok {
+			continue
+		}
+		if !p.keys[key] {
+			if len(p.keys) >= maxKeys {
+				dropped++
+				continue
+			}
+			p.keys[key] = true
+		}
+		metrics.GetOrRegisterGaugeFloat64(fmt.Sprintf("exec.%s.%s", p.name, key), nil).Update(f)
+	}
+	if dropped > 0 {
+		if errmsg != "" {
+			errmsg += ": "
+		}
+		errmsg += fmt.Sprintf("%d keys not registered as metrics, limit of %d keys reached", dropped, maxKeys)
+	}
+
+	// Return data
+	data["health"] = health(exitCode)
+	data["exit_code"] = p.ExitCode.Value()
+	data["duration"] = p.Duration.Value()
+	if errmsg != "" {
+		data["error"] = errmsg
+	}
+	return data
+}
+
+// health maps an exit code to a plugin health.
+func health(exitCode int) string {
+	switch exitCode {
+	case 0:
+		return plugins.HealthOK
+	case 1:
+		return plugins.HealthWarning
+	case 2:
+		return plugins.HealthCritical
+	}
+	return plugins.HealthUnknown
+}
+
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
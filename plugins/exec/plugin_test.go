@@ -0,0 +1,129 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package exec
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+// waitSnapshot takes snapshots until the first run of the command has
+// finished.
+func waitSnapshot(t *testing.T, p *Plugin) map[string]interface{} {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		data, err := p.Snapshot()
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := data.(map[string]interface{})
+		if m["health"] != plugins.HealthUnknown || m["exit_code"] != nil {
+			return m
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("command did not finish")
+	return nil
+}
+
+func TestSnapshot(t *testing.T) {
+	p, err := NewPlugin("snapshot", &Config{
+		Command: "sh",
+		Args:    []string{"-c", `echo "queue_length=12"; echo "health=bad"; exit 1`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := waitSnapshot(t, p)
+	if got := m["health"]; got != plugins.HealthWarning {
+		t.Errorf("expected warning health; got %v", got)
+	}
+	if got := m["exit_code"]; got != int64(1) {
+		t.Errorf("expected exit code 1; got %v", got)
+	}
+	if got := m["queue_length"]; got != int64(12) {
+		t.Errorf("expected queue_length of 12; got %v", got)
+	}
+	// Reserved keys of the output are renamed
+	if got := m["output_health"]; got != "bad" {
+		t.Errorf("expected output_health of bad; got %v", got)
+	}
+	if g, ok := metrics.Get("exec.snapshot.queue_length").(metrics.GaugeFloat64); !ok || g.Value() != 12 {
+		t.Errorf("expected gauge exec.snapshot.queue_length of 12; got %v", g)
+	}
+}
+
+func TestSnapshotAsync(t *testing.T) {
+	p, err := NewPlugin("async", &Config{
+		Command: "sh",
+		Args:    []string{"-c", "sleep 1; echo up=1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A slow command does not block the snapshot
+	start := time.Now()
+	data, err := p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("expected snapshot to return immediately; took %v", d)
+	}
+	if got := data.(map[string]interface{})["health"]; got != plugins.HealthUnknown {
+		t.Errorf("expected unknown health before the first run finished; got %v", got)
+	}
+
+	m := waitSnapshot(t, p)
+	if got := m["up"]; got != int64(1) {
+		t.Errorf("expected up of 1; got %v", got)
+	}
+}
+
+func TestSnapshotTimeout(t *testing.T) {
+	p, err := NewPlugin("timeout", &Config{
+		Command: "sleep",
+		Args:    []string{"10"},
+		Timeout: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := waitSnapshot(t, p)
+	if got := m["exit_code"]; got != int64(-1) {
+		t.Errorf("expected exit code -1; got %v", got)
+	}
+	if got, _ := m["error"].(string); !strings.HasPrefix(got, "timed out") {
+		t.Errorf("expected timeout error; got %q", got)
+	}
+}
+
+func TestSnapshotMaxKeys(t *testing.T) {
+	p, err := NewPlugin("maxkeys", &Config{
+		Command: "sh",
+		Args:    []string{"-c", "i=0; while [ $i -lt 105 ]; do echo k$i=$i; i=$((i+1)); done"},
+		Format:  FormatKeyValue,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := waitSnapshot(t, p)
+	if got := len(p.keys); got != maxKeys {
+		t.Errorf("expected %d keys registered; got %d", maxKeys, got)
+	}
+	if got, _ := m["error"].(string); !strings.HasPrefix(got, "5 keys not registered") {
+		t.Errorf("expected error about dropped keys; got %q", got)
+	}
+	// All keys are reported, even if not registered as metrics
+	if got := m["k104"]; got != int64(104) {
+		t.Errorf("expected k104 of 104; got %v", got)
+	}
+}
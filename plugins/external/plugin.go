@@ -0,0 +1,256 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+// Package external runs plugins as long-running subprocesses, so plugins
+// can be written in any language without recompiling metronomed.
+//
+// Metronomed talks to the plugin via line-delimited JSON-RPC 2.0 over
+// the stdin and stdout of the subprocess. Each request and response is
+// a single line of JSON. The plugin must implement these methods:
+//
+//	describe  returns an object like {"name":"...","version":"..."}
+//	snapshot  returns the current metrics as a JSON value; if it is
+//	          not an object, it is reported under "value"
+//	shutdown  asks the plugin to exit
+//
+// Stderr of the plugin is passed through to metronomed. If the plugin
+// crashes or stops responding, it is restarted with exponential backoff.
+package external
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+var (
+	defaultTimeout    = 10 * time.Second
+	defaultMinBackoff = 1 * time.Second
+	defaultMaxBackoff = 1 * time.Minute
+
+	errClosed = errors.New("plugin closed")
+)
+
+// Config is the configuration for an external plugin.
+type Config struct {
+	// Command that starts the plugin.
+	Command string
+	// Args to pass to the command.
+	Args []string
+	// Timeout for a single request to the plugin (default: 10s).
+	Timeout time.Duration
+	// MinBackoff is the time to wait before the first restart of a
+	// crashed plugin (default: 1s). It doubles with every failed
+	// restart, up to MaxBackoff (default: 1m).
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// Description is returned by the plugin on "describe".
+type Description struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Plugin runs an external plugin as a subprocess.
+type Plugin struct {
+	name       string
+	command    string
+	args       []string
+	timeout    time.Duration
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	mu          sync.Mutex // guards the fields below
+	proc        *process
+	started     bool
+	description *Description
+	backoff     time.Duration
+	nextStart   time.Time
+	lastErr     error
+	closed      bool
+
+	Restarts metrics.Counter // number of restarts of the subprocess
+}
+
+// NewPlugin initializes a new external plugin. The subprocess is started
+// on the first snapshot. Pass a name to differentiate between plugins.
+func NewPlugin(name string, config *Config) (*Plugin, error) {
+	if name == "" {
+		return nil, errors.New("no name specified")
+	}
+	if config == nil {
+		return nil, errors.New("no configuration specified")
+	}
+	if config.Command == "" {
+		return nil, errors.New("no command specified")
+	}
+
+	plugin := &Plugin{
+		name:       name,
+		command:    config.Command,
+		args:       config.Args,
+		timeout:    config.Timeout,
+		minBackoff: config.MinBackoff,
+		maxBackoff: config.MaxBackoff,
+	}
+	if plugin.timeout <= 0 {
+		plugin.timeout = defaultTimeout
+	}
+	if plugin.minBackoff <= 0 {
+		plugin.minBackoff = defaultMinBackoff
+	}
+	if plugin.maxBackoff <= 0 {
+		plugin.maxBackoff = defaultMaxBackoff
+	}
+	if plugin.maxBackoff < plugin.minBackoff {
+		plugin.maxBackoff = plugin.minBackoff
+	}
+
+	plugin.Restarts = metrics.NewCounter()
+	metrics.Register(fmt.Sprintf("external.%s.restarts", plugin.name), plugin.Restarts)
+
+	return plugin, nil
+}
+
+// Name of the plugin. It is prefixed with "external." so that instances
+// do not collide with other plugins of the same name.
+func (p *Plugin) Name() string {
+	return "external." + p.name
+}
+
+// Description returns what the plugin reported on "describe", or nil if
+// the plugin has not been started successfully yet.
+func (p *Plugin) Description() *Description {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.description
+}
+
+// Snapshot asks the subprocess for a snapshot, (re)starting it if
+// necessary. If the plugin is down, a snapshot with its health and the
+// last error is returned. After Close, the subprocess is not started
+// again and Snapshot returns an error.
+func (p *Plugin) Snapshot() (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, errClosed
+	}
+
+	if p.proc != nil && p.proc.exited() {
+		p.fail(p.proc.exitErr())
+	}
+	if p.proc == nil {
+		if time.Now().Before(p.nextStart) {
+			return p.down(), nil
+		}
+		if err := p.start(); err != nil {
+			p.fail(err)
+			return p.down(), nil
+		}
+	}
+
+	var data interface{}
+	if err := p.proc.call("snapshot", p.timeout, &data); err != nil {
+		if _, ok := err.(*rpcError); ok {
+			// The plugin is alive, but cannot take a snapshot.
+			return map[string]interface{}{
+				"health": plugins.HealthCritical,
+				"error":  err.Error(),
+			}, nil
+		}
+		p.fail(err)
+		return p.down(), nil
+	}
+	p.backoff = 0
+	p.lastErr = nil
+
+	// Report health like on errors. Plugins may report their own health.
+	if m, ok := data.(map[string]interface{}); ok {
+		if _, found := m["health"]; !found {
+			m["health"] = plugins.HealthOK
+		}
+		return m, nil
+	}
+	return map[string]interface{}{
+		"health": plugins.HealthOK,
+		"value":  data,
+	}, nil
+}
+
+// Close asks the subprocess to shut down and kills it if it does not
+// exit within the timeout.
+func (p *Plugin) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	if p.proc == nil {
+		return nil
+	}
+	err := p.proc.shutdown(p.timeout)
+	p.proc = nil
+	return err
+}
+
+// start starts the subprocess and asks it to describe itself.
+func (p *Plugin) start() error {
+	if p.started {
+		p.Restarts.Inc(1)
+	}
+	p.started = true
+
+	proc, err := startProcess(p.command, p.args, os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	var desc Description
+	if err := proc.call("describe", p.timeout, &desc); err != nil {
+		proc.kill()
+		return fmt.Errorf("describe failed: %v", err)
+	}
+	p.proc = proc
+	p.description = &desc
+	return nil
+}
+
+// fail kills the subprocess (if any) and schedules a restart with
+// exponential backoff.
+func (p *Plugin) fail(err error) {
+	if p.proc != nil {
+		p.proc.kill()
+		p.proc = nil
+	}
+	p.lastErr = err
+	if p.backoff == 0 {
+		p.backoff = p.minBackoff
+	} else {
+		p.backoff *= 2
+		if p.backoff > p.maxBackoff {
+			p.backoff = p.maxBackoff
+		}
+	}
+	p.nextStart = time.Now().Add(p.backoff)
+}
+
+// down returns the snapshot for a plugin that is not running.
+func (p *Plugin) down() map[string]interface{} {
+	data := map[string]interface{}{
+		"health":   plugins.HealthUnknown,
+		"restarts": p.Restarts.Count(),
+	}
+	if p.lastErr != nil {
+		data["error"] = p.lastErr.Error()
+	}
+	return data
+}
@@ -0,0 +1,226 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package external
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+const helperEnv = "METRONOME_TEST_EXTERNAL_PLUGIN"
+
+// TestMain runs the test binary as an external plugin if helperEnv is
+// set. The last argument selects how the plugin behaves.
+func TestMain(m *testing.M) {
+	if os.Getenv(helperEnv) == "1" {
+		helperPlugin(os.Args[len(os.Args)-1])
+		os.Exit(0)
+	}
+	os.Setenv(helperEnv, "1")
+	os.Exit(m.Run())
+}
+
+// helperPlugin implements the plugin side of the protocol. Mode is one
+// of "ok", "stale", "exit", "slow", "crash" or "error".
+func helperPlugin(mode string) {
+	w := bufio.NewWriter(os.Stdout)
+	respond := func(id int64, result interface{}) {
+		b, _ := json.Marshal(result)
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":%s}`+"\n", id, b)
+		w.Flush()
+	}
+	count := 0
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			os.Exit(2)
+		}
+		switch req.Method {
+		case "describe":
+			respond(req.ID, Description{Name: "helper", Version: "1.0"})
+		case "shutdown":
+			respond(req.ID, nil)
+			return
+		case "snapshot":
+			count++
+			switch mode {
+			case "stale":
+				// A late response to an earlier request and a line
+				// that is not JSON-RPC at all
+				respond(req.ID-1, map[string]interface{}{"stale": true})
+				fmt.Fprintln(w, "debug: taking snapshot")
+			case "exit":
+				respond(req.ID, map[string]interface{}{"count": count})
+				os.Exit(0)
+			case "slow":
+				time.Sleep(time.Second)
+			case "crash":
+				os.Exit(3)
+			case "error":
+				fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32000,"message":"no data"}}`+"\n", req.ID)
+				w.Flush()
+				continue
+			}
+			respond(req.ID, map[string]interface{}{"count": count})
+		}
+	}
+}
+
+func newHelperPlugin(t *testing.T, mode string, config *Config) *Plugin {
+	if config == nil {
+		config = &Config{}
+	}
+	config.Command = os.Args[0]
+	config.Args = []string{mode}
+	if config.Timeout == 0 {
+		config.Timeout = 5 * time.Second
+	}
+	p, err := NewPlugin(fmt.Sprintf("%s-%d", mode, time.Now().UnixNano()), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func snapshot(t *testing.T, p *Plugin) map[string]interface{} {
+	data, err := p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data.(map[string]interface{})
+}
+
+func TestSnapshot(t *testing.T) {
+	p := newHelperPlugin(t, "ok", nil)
+
+	for i := 1; i <= 3; i++ {
+		m := snapshot(t, p)
+		if got := m["health"]; got != plugins.HealthOK {
+			t.Errorf("expected ok health; got %v", got)
+		}
+		if got := m["count"]; got != float64(i) {
+			t.Errorf("expected count of %d; got %v", i, got)
+		}
+	}
+	want := Description{Name: "helper", Version: "1.0"}
+	if got := p.Description(); got == nil || *got != want {
+		t.Errorf("expected description %+v; got %+v", want, got)
+	}
+
+	// A closed plugin stays closed
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Snapshot(); err != errClosed {
+		t.Errorf("expected %v after close; got %v", errClosed, err)
+	}
+	if p.proc != nil {
+		t.Error("expected no process after close")
+	}
+	if got := p.Restarts.Count(); got != 0 {
+		t.Errorf("expected no restarts; got %d", got)
+	}
+}
+
+func TestSnapshotStaleResponse(t *testing.T) {
+	p := newHelperPlugin(t, "stale", nil)
+	defer p.Close()
+
+	for i := 1; i <= 3; i++ {
+		m := snapshot(t, p)
+		if _, found := m["stale"]; found {
+			t.Fatalf("expected stale response to be skipped; got %v", m)
+		}
+		if got := m["count"]; got != float64(i) {
+			t.Errorf("expected count of %d; got %v", i, got)
+		}
+	}
+}
+
+func TestSnapshotExitAfterResponse(t *testing.T) {
+	// The response written right before the plugin exits is not lost
+	for i := 0; i < 10; i++ {
+		p := newHelperPlugin(t, "exit", nil)
+		m := snapshot(t, p)
+		if got := m["count"]; got != float64(1) {
+			t.Fatalf("#%d: expected count of 1; got %v", i, m)
+		}
+		p.Close()
+	}
+}
+
+func TestSnapshotTimeout(t *testing.T) {
+	p := newHelperPlugin(t, "slow", &Config{Timeout: 100 * time.Millisecond})
+	defer p.Close()
+
+	m := snapshot(t, p)
+	if got := m["health"]; got != plugins.HealthUnknown {
+		t.Errorf("expected unknown health; got %v", got)
+	}
+	if got := m["error"]; got != errTimeout.Error() {
+		t.Errorf("expected error %q; got %v", errTimeout, got)
+	}
+	if p.proc != nil {
+		t.Error("expected plugin that timed out to be killed")
+	}
+}
+
+func TestSnapshotError(t *testing.T) {
+	p := newHelperPlugin(t, "error", nil)
+	defer p.Close()
+
+	m := snapshot(t, p)
+	if got := m["health"]; got != plugins.HealthCritical {
+		t.Errorf("expected critical health; got %v", got)
+	}
+	if got, _ := m["error"].(string); !strings.Contains(got, "no data") {
+		t.Errorf("expected error of the plugin; got %q", got)
+	}
+	if p.proc == nil || p.proc.exited() {
+		t.Error("expected plugin to keep running after an error response")
+	}
+}
+
+func TestSnapshotRestart(t *testing.T) {
+	p := newHelperPlugin(t, "crash", &Config{
+		MinBackoff: 50 * time.Millisecond,
+		MaxBackoff: 80 * time.Millisecond,
+	})
+	defer p.Close()
+
+	m := snapshot(t, p)
+	if got := m["health"]; got != plugins.HealthUnknown {
+		t.Errorf("expected unknown health; got %v", got)
+	}
+	if got, _ := m["error"].(string); got != "plugin exited: exit status 3" {
+		t.Errorf("expected exit error; got %q", got)
+	}
+
+	// No restart before the backoff has passed
+	snapshot(t, p)
+	if got := p.Restarts.Count(); got != 0 {
+		t.Errorf("expected no restarts during backoff; got %d", got)
+	}
+
+	// The backoff doubles up to the maximum
+	for i := 1; i <= 2; i++ {
+		time.Sleep(p.backoff)
+		snapshot(t, p)
+		if got := p.Restarts.Count(); got != int64(i) {
+			t.Errorf("expected %d restarts; got %d", i, got)
+		}
+		if want := 80 * time.Millisecond; p.backoff != want {
+			t.Errorf("expected backoff of %v; got %v", want, p.backoff)
+		}
+	}
+}
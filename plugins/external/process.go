@@ -0,0 +1,212 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package external
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+)
+
+const (
+	// Maximum size of a single response line of the plugin.
+	maxResponseSize = 10 << 20
+)
+
+var (
+	errExited  = errors.New("plugin exited")
+	errTimeout = errors.New("plugin timed out")
+
+	// waitDelay is how long to wait for the rest of stdout after the
+	// plugin has exited, e.g. because a child process it forked still
+	// holds stdout open.
+	waitDelay = 1 * time.Second
+)
+
+// request is a JSON-RPC 2.0 request.
+type request struct {
+	Version string `json:"jsonrpc"`
+	ID      int64  `json:"id"`
+	Method  string `json:"method"`
+}
+
+// response is a JSON-RPC 2.0 response.
+type response struct {
+	Version string          `json:"jsonrpc"`
+	ID      int64           `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *rpcError       `json:"error"`
+}
+
+// rpcError is an error returned by the plugin.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("plugin returned error %d: %s", e.Code, e.Message)
+}
+
+// process is a running plugin subprocess.
+type process struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	enc       *json.Encoder
+	responses chan *response // responses read from stdout
+	eof       chan struct{}  // closed when stdout has been read to the end
+	done      chan struct{}  // closed when the process has exited
+	waitErr   error          // result of cmd.Wait, valid after done is closed
+	nextID    int64
+}
+
+// startProcess starts the plugin command.
+func startProcess(command string, args []string, stderr io.Writer) (*process, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Stderr = stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	// Stdout is a pipe of our own rather than cmd.StdoutPipe, as Wait
+	// closes the latter even if the last responses have not been read.
+	stdout, w, err := os.Pipe()
+	if err != nil {
+		stdin.Close()
+		return nil, err
+	}
+	cmd.Stdout = w
+	err = cmd.Start()
+	w.Close()
+	if err != nil {
+		stdout.Close()
+		return nil, err
+	}
+
+	p := &process{
+		cmd:       cmd,
+		stdin:     stdin,
+		enc:       json.NewEncoder(stdin),
+		responses: make(chan *response),
+		eof:       make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go p.readPump(stdout)
+	go p.wait()
+	return p, nil
+}
+
+// wait waits for the process to exit and reaps it. Responses written
+// before the exit are read before done is closed, but it waits at most
+// waitDelay for stdout to be closed, as a child of the plugin may still
+// hold it open.
+func (p *process) wait() {
+	p.waitErr = p.cmd.Wait()
+	select {
+	case <-p.eof:
+	case <-time.After(waitDelay):
+	}
+	close(p.done)
+}
+
+// readPump reads responses from the plugin until stdout is closed.
+func (p *process) readPump(stdout io.ReadCloser) {
+	defer close(p.eof)
+	defer stdout.Close()
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64<<10), maxResponseSize)
+	for scanner.Scan() {
+		var res response
+		if err := json.Unmarshal(scanner.Bytes(), &res); err != nil {
+			// Skip lines that are not JSON-RPC responses.
+			continue
+		}
+		select {
+		case p.responses <- &res:
+		case <-time.After(time.Second):
+			// Nobody is waiting for this response (anymore).
+		}
+	}
+}
+
+// call sends a request to the plugin and decodes the result into v.
+func (p *process) call(method string, timeout time.Duration, v interface{}) error {
+	p.nextID++
+	id := p.nextID
+	if err := p.enc.Encode(&request{Version: "2.0", ID: id, Method: method}); err != nil {
+		return err
+	}
+
+	deadline := time.After(timeout)
+	for {
+		select {
+		case res := <-p.responses:
+			if res.ID != id {
+				// Stale response to an earlier request that timed out.
+				continue
+			}
+			if res.Error != nil {
+				return res.Error
+			}
+			if v == nil {
+				return nil
+			}
+			return json.Unmarshal(res.Result, v)
+		case <-p.done:
+			return p.exitErr()
+		case <-deadline:
+			return errTimeout
+		}
+	}
+}
+
+// exited returns true if the process has exited.
+func (p *process) exited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// exitErr returns why the process has exited.
+func (p *process) exitErr() error {
+	if p.waitErr == nil {
+		return errExited
+	}
+	return fmt.Errorf("plugin exited: %v", p.waitErr)
+}
+
+// shutdown asks the plugin to exit and kills it if it does not exit
+// within the timeout.
+func (p *process) shutdown(timeout time.Duration) error {
+	err := p.call("shutdown", timeout, nil)
+	p.stdin.Close()
+	select {
+	case <-p.done:
+	case <-time.After(timeout):
+		p.kill()
+	}
+	if p.exited() {
+		// The plugin may exit before responding, which is fine.
+		return nil
+	}
+	return err
+}
+
+// kill kills the process and waits for it to be reaped.
+func (p *process) kill() {
+	p.stdin.Close()
+	if p.cmd.Process != nil {
+		p.cmd.Process.Kill()
+	}
+	<-p.done
+}
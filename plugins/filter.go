@@ -0,0 +1,49 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package plugins
+
+import "path"
+
+// Filter selects names, e.g. of mount points or devices, by include and
+// exclude patterns. Patterns use the syntax of path.Match.
+type Filter struct {
+	include []string
+	exclude []string
+}
+
+// NewFilter creates a Filter. A name matches if it matches none of the
+// exclude patterns and, unless include is empty, one of the include
+// patterns.
+func NewFilter(include, exclude []string) (*Filter, error) {
+	for _, patterns := range [][]string{include, exclude} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &Filter{include: include, exclude: exclude}, nil
+}
+
+// Match returns true if name passes the filter.
+func (f *Filter) Match(name string) bool {
+	if f == nil {
+		return true
+	}
+	for _, pattern := range f.exclude {
+		if ok, _ := path.Match(pattern, name); ok {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, pattern := range f.include {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
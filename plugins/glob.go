@@ -0,0 +1,35 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package plugins
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// GlobDirs returns the directories below base matching any of the
+// patterns, relative to base and sorted. Patterns use the syntax of
+// filepath.Match, e.g. "system.slice/*.service" to select cgroups.
+func GlobDirs(base string, patterns []string) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, pattern := range patterns {
+		matches, _ := filepath.Glob(filepath.Join(base, pattern))
+		for _, match := range matches {
+			if fi, err := os.Stat(match); err != nil || !fi.IsDir() {
+				continue
+			}
+			rel, err := filepath.Rel(base, match)
+			if err != nil || seen[rel] {
+				continue
+			}
+			seen[rel] = true
+			dirs = append(dirs, rel)
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
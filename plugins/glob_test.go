@@ -0,0 +1,36 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGlobDirs(t *testing.T) {
+	base, err := ioutil.TempDir("", "metronome")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(base)
+
+	for _, dir := range []string{"system.slice/b.service", "system.slice/a.service", "user.slice"} {
+		if err := os.MkdirAll(filepath.Join(base, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(base, "system.slice", "c.service"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := GlobDirs(base, []string{"system.slice/*.service", "system.slice/a.*", "*.slice"})
+	want := []string{"system.slice", "system.slice/a.service", "system.slice/b.service", "user.slice"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v; got %v", want, got)
+	}
+}
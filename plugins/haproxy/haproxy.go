@@ -0,0 +1,130 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package haproxy
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Types of entries in the stats.
+const (
+	Frontend = "frontend"
+	Backend  = "backend"
+	Server   = "server"
+	Listener = "listener"
+)
+
+var types = map[string]string{
+	"0": Frontend,
+	"1": Backend,
+	"2": Server,
+	"3": Listener,
+}
+
+// Stat is a line of the HAProxy CSV stats, keyed by the column names
+// of the header, e.g. "scur" or "hrsp_5xx". See the management guide
+// of HAProxy for the meaning of the columns.
+type Stat map[string]string
+
+// Proxy returns the name of the frontend or backend.
+func (s Stat) Proxy() string {
+	return s["pxname"]
+}
+
+// Server returns the name of the server, or "FRONTEND" and "BACKEND"
+// for the aggregated lines of frontends and backends.
+func (s Stat) Server() string {
+	return s["svname"]
+}
+
+// Type returns Frontend, Backend, Server or Listener.
+func (s Stat) Type() string {
+	return types[s["type"]]
+}
+
+// Int returns the value of a column as an integer, or 0 if the column
+// is empty.
+func (s Stat) Int(name string) int64 {
+	v, _ := strconv.ParseInt(s[name], 10, 64)
+	return v
+}
+
+// GetStatsURL fetches the CSV stats from the stats page of HAProxy,
+// e.g. "http://localhost:8404/stats;csv".
+func GetStatsURL(client *http.Client, url, username, password string) ([]Stat, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", res.Status)
+	}
+	return ParseStats(res.Body)
+}
+
+// GetStatsSocket fetches the CSV stats from the admin socket of HAProxy,
+// e.g. "/var/run/haproxy.sock".
+func GetStatsSocket(path string, timeout time.Duration) ([]Stat, error) {
+	conn, err := net.DialTimeout("unix", path, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+	if _, err := io.WriteString(conn, "show stat\n"); err != nil {
+		return nil, err
+	}
+	// HAProxy closes the connection after the response
+	return ParseStats(conn)
+}
+
+// ParseStats parses the HAProxy CSV stats, which start with a header
+// like "# pxname,svname,qcur,...".
+func ParseStats(r io.Reader) ([]Stat, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	if len(header) == 0 || !strings.HasPrefix(header[0], "# ") {
+		return nil, fmt.Errorf("invalid stats header %q", strings.Join(header, ","))
+	}
+	header[0] = strings.TrimPrefix(header[0], "# ")
+
+	var stats []Stat
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		s := make(Stat)
+		for i, value := range record {
+			if i < len(header) && header[i] != "" {
+				s[header[i]] = value
+			}
+		}
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
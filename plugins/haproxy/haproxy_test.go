@@ -0,0 +1,90 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package haproxy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseStats(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "stats.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	stats, err := ParseStats(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 4 {
+		t.Fatalf("expected %d lines; got %d", 4, len(stats))
+	}
+
+	tests := []struct {
+		Proxy    string
+		Server   string
+		Type     string
+		Status   string
+		Sessions int64
+		Total    int64
+		HRsp5xx  int64
+		Check    string
+	}{
+		{"http-in", "FRONTEND", Frontend, "OPEN", 12, 15000, 50, ""},
+		{"app", "web1", Server, "UP", 5, 7000, 20, "L7OK"},
+		{"app", "web2", Server, "DOWN", 0, 6000, 30, "L4CON"},
+		{"app", "BACKEND", Backend, "UP", 5, 13000, 50, ""},
+	}
+	for i, test := range tests {
+		s := stats[i]
+		if s.Proxy() != test.Proxy || s.Server() != test.Server {
+			t.Errorf("#%d: expected %s/%s; got %s/%s", i, test.Proxy, test.Server, s.Proxy(), s.Server())
+		}
+		if s.Type() != test.Type {
+			t.Errorf("#%d: expected type %q; got %q", i, test.Type, s.Type())
+		}
+		if s["status"] != test.Status {
+			t.Errorf("#%d: expected status %q; got %q", i, test.Status, s["status"])
+		}
+		if got := s.Int("scur"); got != test.Sessions {
+			t.Errorf("#%d: expected %d current sessions; got %d", i, test.Sessions, got)
+		}
+		if got := s.Int("stot"); got != test.Total {
+			t.Errorf("#%d: expected %d total sessions; got %d", i, test.Total, got)
+		}
+		if got := s.Int("hrsp_5xx"); got != test.HRsp5xx {
+			t.Errorf("#%d: expected %d 5xx responses; got %d", i, test.HRsp5xx, got)
+		}
+		if s["check_status"] != test.Check {
+			t.Errorf("#%d: expected check status %q; got %q", i, test.Check, s["check_status"])
+		}
+		// The trailing comma of each line adds no column
+		if _, found := s[""]; found {
+			t.Errorf("#%d: expected no column without a name", i)
+		}
+	}
+
+	// Empty columns are reported as zero
+	if got := stats[0].Int("qcur"); got != 0 {
+		t.Errorf("expected %d; got %d", 0, got)
+	}
+}
+
+func TestParseStatsInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"pxname,svname,scur\nhttp-in,FRONTEND,1\n",
+		"# pxname,svname,scur\nhttp-in,\"FRONTEND,1\n",
+	}
+	for _, input := range tests {
+		if _, err := ParseStats(strings.NewReader(input)); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package haproxy
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+const (
+	defaultTimeout = 10 * time.Second
+)
+
+// Config is the configuration for the HAProxy plugin.
+type Config struct {
+	// URL of the CSV stats, e.g. "http://localhost:8404/stats;csv".
+	URL string
+
+	// Username and Password for HTTP basic authentication with URL.
+	Username string
+	Password string
+
+	// Socket is the path of the admin socket, e.g.
+	// "/var/run/haproxy.sock". Mutually exclusive with URL.
+	Socket string
+
+	// Timeout for fetching the stats (default: 10s).
+	Timeout time.Duration
+
+	// IncludeProxies and ExcludeProxies are patterns of the names of
+	// frontends and backends to report, as used by path.Match.
+	// Servers are reported along with their backend.
+	IncludeProxies []string
+	ExcludeProxies []string
+}
+
+// Plugin watches the frontends, backends and servers of HAProxy.
+//
+// Rates of counters are derived from the difference between two
+// snapshots, so the first snapshot reports zero rates.
+type Plugin struct {
+	url      string
+	username string
+	password string
+	socket   string
+	timeout  time.Duration
+	client   *http.Client
+	filter   *plugins.Filter
+	last     map[string]Stat
+	lastTime time.Time
+}
+
+// NewPlugin initializes a new Plugin to watch HAProxy.
+func NewPlugin(config *Config) (*Plugin, error) {
+	if config == nil {
+		return nil, errors.New("no configuration specified")
+	}
+	if config.URL == "" && config.Socket == "" {
+		return nil, errors.New("no url or socket specified")
+	}
+	if config.URL != "" && config.Socket != "" {
+		return nil, errors.New("specify either url or socket, not both")
+	}
+	filter, err := plugins.NewFilter(config.IncludeProxies, config.ExcludeProxies)
+	if err != nil {
+		return nil, err
+	}
+	plugin := &Plugin{
+		url:      config.URL,
+		username: config.Username,
+		password: config.Password,
+		socket:   config.Socket,
+		timeout:  config.Timeout,
+		filter:   filter,
+	}
+	if plugin.timeout <= 0 {
+		plugin.timeout = defaultTimeout
+	}
+	plugin.client = &http.Client{Timeout: plugin.timeout}
+	return plugin, nil
+}
+
+// Name of the plugin.
+func (p *Plugin) Name() string {
+	return "haproxy"
+}
+
+// Snapshot returns the sessions, queues, responses and health of all
+// frontends, backends and servers, keyed by "proxy/FRONTEND",
+// "proxy/BACKEND" and "proxy/server". The health of the plugin is
+// critical if a backend is down or HAProxy is unreachable, and warning
+// if a server is down.
+func (p *Plugin) Snapshot() (interface{}, error) {
+	var stats []Stat
+	var err error
+	if p.socket != "" {
+		stats, err = GetStatsSocket(p.socket, p.timeout)
+	} else {
+		stats, err = GetStatsURL(p.client, p.url, p.username, p.password)
+	}
+	if err != nil {
+		return map[string]interface{}{
+			"health": plugins.HealthCritical,
+			"error":  err.Error(),
+		}, nil
+	}
+	now := time.Now()
+	secs := now.Sub(p.lastTime).Seconds()
+
+	health := plugins.HealthOK
+	entries := make(map[string]interface{})
+	cur := make(map[string]Stat)
+	for _, s := range stats {
+		if s.Type() == Listener || !p.filter.Match(s.Proxy()) {
+			continue
+		}
+		name := s.Proxy() + "/" + s.Server()
+		cur[name] = s
+		prev, found := p.last[name]
+		if !found {
+			prev = s
+		}
+		entry := p.entry(name, s, prev, secs)
+		entries[name] = entry
+
+		if !entry["up"].(bool) {
+			switch s.Type() {
+			case Backend:
+				health = plugins.HealthCritical
+			case Server:
+				if health == plugins.HealthOK {
+					health = plugins.HealthWarning
+				}
+			}
+		}
+	}
+	p.last = cur
+	p.lastTime = now
+
+	// Return data
+	return map[string]interface{}{
+		"health":  health,
+		"proxies": entries,
+	}, nil
+}
+
+// entry computes the metrics of a frontend, backend or server from two
+// samples taken secs seconds apart, and updates the registered metrics.
+func (p *Plugin) entry(name string, cur, prev Stat, secs float64) map[string]interface{} {
+	prefix := "haproxy." + strings.Replace(name, "/", ".", -1) + "."
+
+	// Servers in maintenance or without checks are not considered down,
+	// neither are servers going down ("UP 1/3")
+	status := cur["status"]
+	up := !strings.HasPrefix(status, "DOWN")
+
+	values := map[string]int64{
+		"sessions_current": cur.Int("scur"),
+		"sessions_limit":   cur.Int("slim"),
+		"session_rate":     cur.Int("rate"), // sessions in the last second
+		"queue_current":    cur.Int("qcur"),
+		"hrsp_5xx":         cur.Int("hrsp_5xx"),
+		"check_failures":   cur.Int("chkfail"),
+	}
+	rates := map[string]float64{
+		"sessions_per_sec":       plugins.RateInt(cur.Int("stot"), prev.Int("stot"), secs),
+		"bytes_in_per_sec":       plugins.RateInt(cur.Int("bin"), prev.Int("bin"), secs),
+		"bytes_out_per_sec":      plugins.RateInt(cur.Int("bout"), prev.Int("bout"), secs),
+		"hrsp_5xx_per_sec":       plugins.RateInt(cur.Int("hrsp_5xx"), prev.Int("hrsp_5xx"), secs),
+		"request_errors_per_sec": plugins.RateInt(cur.Int("ereq"), prev.Int("ereq"), secs),
+		"conn_errors_per_sec":    plugins.RateInt(cur.Int("econ"), prev.Int("econ"), secs),
+	}
+
+	data := map[string]interface{}{
+		"type":   cur.Type(),
+		"status": status,
+		"up":     up,
+	}
+	if check := cur["check_status"]; check != "" {
+		data["check_status"] = check
+	}
+	for key, value := range values {
+		metrics.GetOrRegisterGauge(prefix+key, nil).Update(value)
+		data[key] = value
+	}
+	for key, value := range rates {
+		metrics.GetOrRegisterGaugeFloat64(prefix+key, nil).Update(value)
+		data[key] = value
+	}
+	var upValue int64
+	if up {
+		upValue = 1
+	}
+	metrics.GetOrRegisterGauge(prefix+"up", nil).Update(upValue)
+	return data
+}
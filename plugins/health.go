@@ -0,0 +1,14 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package plugins
+
+// Health values a plugin reports under the "health" key of its snapshot.
+// They follow the Nagios convention of OK, WARNING, CRITICAL and UNKNOWN.
+const (
+	HealthOK       = "ok"
+	HealthWarning  = "warning"
+	HealthCritical = "critical"
+	HealthUnknown  = "unknown"
+)
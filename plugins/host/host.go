@@ -0,0 +1,84 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package host
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+var (
+	// UtmpFile is the file with the logged-in users. It is looked up
+	// below plugins.HostRoot.
+	UtmpFile = "/var/run/utmp"
+)
+
+// GetUptime returns the uptime in seconds from /proc/uptime.
+func GetUptime() (float64, error) {
+	data, err := ioutil.ReadFile(plugins.ProcPath("uptime"))
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, errors.New("invalid uptime format")
+	}
+	return strconv.ParseFloat(fields[0], 64)
+}
+
+// GetNumCPUs returns the number of online CPUs from /proc/cpuinfo.
+func GetNumCPUs() (int, error) {
+	f, err := os.Open(plugins.ProcPath("cpuinfo"))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	n := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), "processor") {
+			n++
+		}
+	}
+	return n, scanner.Err()
+}
+
+// GetFileHandles returns the number of allocated file handles and the
+// maximum (fs.file-max) from /proc/sys/fs/file-nr.
+func GetFileHandles() (allocated, max int64, err error) {
+	data, err := ioutil.ReadFile(plugins.ProcPath("sys", "fs", "file-nr"))
+	if err != nil {
+		return 0, 0, err
+	}
+	// allocated, allocated but unused (always 0 since 2.6), maximum
+	fields := strings.Fields(string(data))
+	if len(fields) != 3 {
+		return 0, 0, fmt.Errorf("invalid file-nr format %q", data)
+	}
+	if allocated, err = strconv.ParseInt(fields[0], 10, 64); err != nil {
+		return 0, 0, err
+	}
+	if max, err = strconv.ParseInt(fields[2], 10, 64); err != nil {
+		return 0, 0, err
+	}
+	return allocated, max, nil
+}
+
+// ReadSysctl returns the value of a file below /proc/sys,
+// e.g. ReadSysctl("kernel", "osrelease").
+func ReadSysctl(elem ...string) (string, error) {
+	data, err := ioutil.ReadFile(plugins.ProcPath(append([]string{"sys"}, elem...)...))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package host
+
+import (
+	"os"
+	"strconv"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+// Plugin reports a summary of a machine: uptime, kernel, number of
+// CPUs, logged-in users, file handles and available entropy.
+type Plugin struct {
+	uptime      metrics.GaugeFloat64
+	users       metrics.Gauge
+	sessions    metrics.Gauge
+	fileHandles metrics.Gauge
+	fileMax     metrics.Gauge
+	entropy     metrics.Gauge
+}
+
+// NewPlugin initializes a new Plugin to report the host summary.
+func NewPlugin() (*Plugin, error) {
+	p := &Plugin{}
+	p.uptime = metrics.NewGaugeFloat64()
+	metrics.Register("host.uptime", p.uptime)
+	p.users = metrics.NewGauge()
+	metrics.Register("host.users", p.users)
+	p.sessions = metrics.NewGauge()
+	metrics.Register("host.sessions", p.sessions)
+	p.fileHandles = metrics.NewGauge()
+	metrics.Register("host.file_handles", p.fileHandles)
+	p.fileMax = metrics.NewGauge()
+	metrics.Register("host.file_max", p.fileMax)
+	p.entropy = metrics.NewGauge()
+	metrics.Register("host.entropy_avail", p.entropy)
+	return p, nil
+}
+
+// Name of the plugin.
+func (p *Plugin) Name() string {
+	return "host"
+}
+
+// Snapshot returns the host summary. Values that cannot be determined,
+// e.g. because of missing permissions, are omitted.
+func (p *Plugin) Snapshot() (interface{}, error) {
+	uptime, err := GetUptime()
+	if err != nil {
+		return nil, err
+	}
+	p.uptime.Update(uptime)
+	data := map[string]interface{}{
+		"uptime": p.uptime.Value(),
+	}
+
+	if hostname, err := ReadSysctl("kernel", "hostname"); err == nil {
+		data["hostname"] = hostname
+	} else if hostname, err := os.Hostname(); err == nil {
+		data["hostname"] = hostname
+	}
+	if bootTime, err := plugins.BootTime(); err == nil {
+		data["boot_time"] = bootTime
+	}
+	if release, err := ReadSysctl("kernel", "osrelease"); err == nil {
+		data["kernel_version"] = release
+	}
+	if n, err := GetNumCPUs(); err == nil {
+		data["num_cpus"] = n
+	}
+	if sessions, users, err := GetUsers(); err == nil {
+		p.sessions.Update(int64(sessions))
+		p.users.Update(int64(users))
+		data["sessions"] = p.sessions.Value()
+		data["users"] = p.users.Value()
+	}
+	if allocated, max, err := GetFileHandles(); err == nil {
+		p.fileHandles.Update(allocated)
+		p.fileMax.Update(max)
+		data["file_handles"] = p.fileHandles.Value()
+		data["file_max"] = p.fileMax.Value()
+		if max > 0 {
+			data["file_handles_percent"] = float64(allocated) / float64(max) * 100.0
+		}
+	}
+	if s, err := ReadSysctl("kernel", "random", "entropy_avail"); err == nil {
+		if entropy, err := strconv.ParseInt(s, 10, 64); err == nil {
+			p.entropy.Update(entropy)
+			data["entropy_avail"] = p.entropy.Value()
+		}
+	}
+
+	// Return data
+	return data, nil
+}
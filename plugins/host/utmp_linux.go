@@ -0,0 +1,64 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+// +build linux
+// +build 386 amd64 arm arm64
+
+package host
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+// Layout of struct utmp of glibc on little-endian Linux, see utmp(5).
+// The record has the same size on 32 and 64 bit, as 64 bit platforms
+// use 32 bit time fields for compatibility.
+const (
+	utmpRecordSize  = 384
+	utmpTypeOffset  = 0
+	utmpUserOffset  = 44
+	utmpUserSize    = 32
+	utmpUserProcess = 7
+)
+
+// GetUsers returns the number of login sessions and of distinct users
+// logged in from UtmpFile.
+func GetUsers() (sessions, users int, err error) {
+	f, err := os.Open(plugins.HostPath(UtmpFile))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+	return ParseUtmp(f)
+}
+
+// ParseUtmp counts the login sessions and distinct users in the
+// contents of a utmp file.
+func ParseUtmp(r io.Reader) (sessions, users int, err error) {
+	names := make(map[string]bool)
+	record := make([]byte, utmpRecordSize)
+	for {
+		if _, err := io.ReadFull(r, record); err == io.EOF {
+			break
+		} else if err != nil {
+			return 0, 0, err
+		}
+		typ := int32(binary.LittleEndian.Uint32(record[utmpTypeOffset:]))
+		if typ != utmpUserProcess {
+			continue
+		}
+		user := record[utmpUserOffset : utmpUserOffset+utmpUserSize]
+		if i := bytes.IndexByte(user, 0); i >= 0 {
+			user = user[:i]
+		}
+		sessions++
+		names[string(user)] = true
+	}
+	return sessions, len(names), nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+// +build linux
+// +build 386 amd64 arm arm64
+
+package host
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+// utmpRecord returns a utmp record of the given type and user.
+func utmpRecord(typ int32, user string) []byte {
+	record := make([]byte, utmpRecordSize)
+	binary.LittleEndian.PutUint32(record[utmpTypeOffset:], uint32(typ))
+	copy(record[utmpUserOffset:utmpUserOffset+utmpUserSize], user)
+	return record
+}
+
+func TestGetUsers(t *testing.T) {
+	root, err := ioutil.TempDir("", "metronome")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	defer func(root string) { plugins.HostRoot = root }(plugins.HostRoot)
+	plugins.HostRoot = root
+
+	var buf bytes.Buffer
+	buf.Write(utmpRecord(2, "reboot")) // BOOT_TIME
+	buf.Write(utmpRecord(utmpUserProcess, "alice"))
+	buf.Write(utmpRecord(utmpUserProcess, "bob"))
+	buf.Write(utmpRecord(utmpUserProcess, "alice"))
+	buf.Write(utmpRecord(8, "bob")) // DEAD_PROCESS
+	filename := filepath.Join(root, UtmpFile)
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, users, err := GetUsers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sessions != 3 {
+		t.Errorf("expected 3 sessions; got %d", sessions)
+	}
+	if users != 2 {
+		t.Errorf("expected 2 users; got %d", users)
+	}
+
+	// A truncated record is an error
+	if _, _, err := ParseUtmp(bytes.NewReader(buf.Bytes()[:utmpRecordSize+10])); err == nil {
+		t.Error("expected error on truncated record")
+	}
+}
@@ -0,0 +1,14 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+// +build !linux !386,!amd64,!arm,!arm64
+
+package host
+
+import "errors"
+
+// GetUsers is only supported on Linux platforms with a known utmp layout.
+func GetUsers() (sessions, users int, err error) {
+	return 0, 0, errors.New("host: reading utmp is not supported on this platform")
+}
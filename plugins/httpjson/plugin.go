@@ -0,0 +1,195 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package httpjson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+const (
+	defaultTimeout = 10 * time.Second
+)
+
+var (
+	// reservedNames are keys of the snapshot that metrics cannot use.
+	reservedNames = map[string]bool{"health": true, "missing": true, "error": true}
+)
+
+// Config is the configuration for the httpjson plugin.
+type Config struct {
+	// URL of the JSON document, e.g. "http://localhost:6060/debug/vars"
+	// for the expvar package of Go.
+	URL string
+
+	// Headers to send with the request, e.g. "Accept".
+	Headers map[string]string
+
+	// Username and Password for HTTP basic authentication, or
+	// BearerToken for token authentication.
+	Username    string
+	Password    string
+	BearerToken string
+
+	// Timeout for fetching the document (default: 10s).
+	Timeout time.Duration
+
+	// Metrics maps names of metrics to selectors of values in the
+	// document, e.g. "heap_alloc" to "$.memstats.HeapAlloc".
+	// See Selector for the syntax. The names "health", "missing" and
+	// "error" are reserved for the status of the plugin.
+	Metrics map[string]string
+}
+
+// Plugin polls a JSON document over HTTP and reports values selected
+// from it as metrics.
+type Plugin struct {
+	name      string
+	url       string
+	headers   map[string]string
+	username  string
+	password  string
+	token     string
+	client    *http.Client
+	names     []string // names of the metrics, sorted
+	selectors map[string]Selector
+}
+
+// NewPlugin initializes a new Plugin to poll a JSON document.
+// Pass a name to differentiate between different documents.
+func NewPlugin(name string, config *Config) (*Plugin, error) {
+	if name == "" {
+		return nil, errors.New("no name specified")
+	}
+	if config == nil {
+		return nil, errors.New("no configuration specified")
+	}
+	if config.URL == "" {
+		return nil, errors.New("no url specified")
+	}
+	if _, err := url.Parse(config.URL); err != nil {
+		return nil, err
+	}
+	if len(config.Metrics) == 0 {
+		return nil, errors.New("no metrics specified")
+	}
+	if config.Username != "" && config.BearerToken != "" {
+		return nil, errors.New("specify either username or bearer token, not both")
+	}
+	plugin := &Plugin{
+		name:      name,
+		url:       config.URL,
+		headers:   config.Headers,
+		username:  config.Username,
+		password:  config.Password,
+		token:     config.BearerToken,
+		selectors: make(map[string]Selector),
+	}
+	for metric, s := range config.Metrics {
+		if reservedNames[metric] {
+			return nil, fmt.Errorf("metric %s: name is reserved", metric)
+		}
+		sel, err := ParseSelector(s)
+		if err != nil {
+			return nil, fmt.Errorf("metric %s: %v", metric, err)
+		}
+		plugin.names = append(plugin.names, metric)
+		plugin.selectors[metric] = sel
+	}
+	sort.Strings(plugin.names)
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	plugin.client = &http.Client{Timeout: timeout}
+	return plugin, nil
+}
+
+// Name of the plugin. It is prefixed with "httpjson." so that instances
+// do not collide with other plugins of the same name.
+func (p *Plugin) Name() string {
+	return "httpjson." + p.name
+}
+
+// Snapshot fetches the document and returns the selected values. The
+// health is critical if the document cannot be fetched, and warning if
+// a selector matches no number; those metrics are listed under
+// "missing".
+func (p *Plugin) Snapshot() (interface{}, error) {
+	doc, err := p.fetch()
+	if err != nil {
+		return map[string]interface{}{
+			"health": plugins.HealthCritical,
+			"error":  err.Error(),
+		}, nil
+	}
+
+	data := make(map[string]interface{})
+	var missing []string
+	for _, metric := range p.names {
+		v, found := p.selectors[metric].Select(doc)
+		if !found {
+			missing = append(missing, metric)
+			continue
+		}
+		f, ok := Number(v)
+		if !ok {
+			missing = append(missing, metric)
+			continue
+		}
+		metrics.GetOrRegisterGaugeFloat64(fmt.Sprintf("httpjson.%s.%s", p.name, metric), nil).Update(f)
+		data[metric] = f
+	}
+	data["health"] = plugins.HealthOK
+	if len(missing) > 0 {
+		data["health"] = plugins.HealthWarning
+		data["missing"] = missing
+	}
+
+	// Return data
+	return data, nil
+}
+
+// fetch returns the decoded document.
+func (p *Plugin) fetch() (interface{}, error) {
+	req, err := http.NewRequest("GET", p.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	for name, value := range p.headers {
+		req.Header.Set(name, value)
+	}
+	if p.username != "" {
+		req.SetBasicAuth(p.username, p.password)
+	}
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", res.Status)
+	}
+	var doc interface{}
+	dec := json.NewDecoder(res.Body)
+	dec.UseNumber() // keep the precision of large integers until converted
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package httpjson
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+func TestNewPluginReservedNames(t *testing.T) {
+	for _, name := range []string{"health", "missing", "error"} {
+		_, err := NewPlugin("reserved", &Config{
+			URL:     "http://localhost:6060/debug/vars",
+			Metrics: map[string]string{name: "$.memstats.HeapAlloc"},
+		})
+		if err == nil {
+			t.Errorf("expected error for metric named %q", name)
+		}
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"memstats": {"HeapAlloc": 1024, "NumGC": 7}, "name": "app"}`)
+	}))
+	defer srv.Close()
+
+	p, err := NewPlugin("snapshot", &Config{
+		URL:     srv.URL,
+		Timeout: time.Second,
+		Metrics: map[string]string{
+			"heap_alloc": "$.memstats.HeapAlloc",
+			"num_gc":     "memstats.NumGC",
+			"name":       "name",
+			"absent":     "$.memstats.StackInuse",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := data.(map[string]interface{})
+	if got := m["health"]; got != plugins.HealthWarning {
+		t.Errorf("expected health warning; got %v", got)
+	}
+	if got := m["heap_alloc"]; got != 1024.0 {
+		t.Errorf("expected heap_alloc of 1024; got %v", got)
+	}
+	if got := m["num_gc"]; got != 7.0 {
+		t.Errorf("expected num_gc of 7; got %v", got)
+	}
+	if got, want := m["missing"], []string{"absent", "name"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected missing %v; got %v", want, got)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package httpjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Selector selects a value from a JSON document. It is parsed from a
+// subset of JSONPath: an optional "$", followed by fields separated by
+// dots and array indexes in brackets, e.g. "$.memstats.HeapAlloc" or
+// "servers[0].load". Fields with dots or brackets in their name are
+// quoted in brackets, e.g. `$["cmdline.count"]`.
+type Selector []interface{} // string for fields, int for indexes
+
+// ParseSelector parses a selector like "$.memstats.HeapAlloc".
+func ParseSelector(s string) (Selector, error) {
+	var sel Selector
+	rest := strings.TrimPrefix(s, "$")
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			i := strings.IndexAny(rest, ".[")
+			if i < 0 {
+				i = len(rest)
+			}
+			if i == 0 {
+				return nil, fmt.Errorf("empty field in selector %q", s)
+			}
+			sel = append(sel, rest[:i])
+			rest = rest[i:]
+		case '[':
+			i := strings.Index(rest, "]")
+			if i < 0 {
+				return nil, fmt.Errorf("unterminated bracket in selector %q", s)
+			}
+			inner := rest[1:i]
+			if strings.HasPrefix(inner, `"`) || strings.HasPrefix(inner, "'") {
+				// Quoted fields may contain "]", so find the closing quote
+				quote := inner[0]
+				j := strings.IndexByte(rest[2:], quote)
+				if j < 0 || 2+j+1 >= len(rest) || rest[2+j+1] != ']' {
+					return nil, fmt.Errorf("invalid quoted field in selector %q", s)
+				}
+				sel = append(sel, rest[2:2+j])
+				rest = rest[2+j+2:]
+				continue
+			}
+			n, err := strconv.Atoi(inner)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid index %q in selector %q", inner, s)
+			}
+			sel = append(sel, n)
+			rest = rest[i+1:]
+		default:
+			if len(sel) > 0 || strings.HasPrefix(s, "$") {
+				return nil, fmt.Errorf("invalid selector %q", s)
+			}
+			// Allow omitting the leading "$." as in "memstats.HeapAlloc"
+			rest = "." + rest
+		}
+	}
+	if len(sel) == 0 {
+		return nil, fmt.Errorf("empty selector %q", s)
+	}
+	return sel, nil
+}
+
+// Select returns the value of a document decoded with encoding/json
+// the selector points to, and false if there is none.
+func (sel Selector) Select(doc interface{}) (interface{}, bool) {
+	v := doc
+	for _, elem := range sel {
+		switch e := elem.(type) {
+		case string:
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if v, ok = m[e]; !ok {
+				return nil, false
+			}
+		case int:
+			a, ok := v.([]interface{})
+			if !ok || e >= len(a) {
+				return nil, false
+			}
+			v = a[e]
+		}
+	}
+	return v, true
+}
+
+// Number converts a selected value to a number. Booleans are converted
+// to 0 and 1, and strings are parsed.
+func Number(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,134 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package httpjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		Input    string
+		Expected Selector
+	}{
+		{"$.memstats.HeapAlloc", Selector{"memstats", "HeapAlloc"}},
+		// Implicit "$."
+		{"memstats.HeapAlloc", Selector{"memstats", "HeapAlloc"}},
+		{"uptime", Selector{"uptime"}},
+		// Indexes
+		{"$.memstats.PauseNs[0]", Selector{"memstats", "PauseNs", 0}},
+		{"servers[12].load", Selector{"servers", 12, "load"}},
+		{"$[1][2]", Selector{1, 2}},
+		{"[0].value", Selector{0, "value"}},
+		// Quoted fields
+		{`$["cmdline.count"]`, Selector{"cmdline.count"}},
+		{`$['cmdline.count'].total`, Selector{"cmdline.count", "total"}},
+		{`stats["a]b"][3]`, Selector{"stats", "a]b", 3}},
+		{`$[""]`, Selector{""}},
+	}
+	for _, test := range tests {
+		sel, err := ParseSelector(test.Input)
+		if err != nil {
+			t.Errorf("%s: %v", test.Input, err)
+			continue
+		}
+		if !reflect.DeepEqual(sel, test.Expected) {
+			t.Errorf("%s: expected %#v; got %#v", test.Input, test.Expected, sel)
+		}
+	}
+}
+
+func TestParseSelectorInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"$",
+		"$memstats",
+		"$.",
+		"$.memstats..HeapAlloc",
+		"$.memstats.",
+		"$.PauseNs[0",
+		"$.PauseNs[-1]",
+		"$.PauseNs[x]",
+		"$.PauseNs[]",
+		"$.PauseNs[0]x",
+		`$["cmdline.count]`,
+		`$["cmdline"count"]`,
+	}
+	for _, input := range tests {
+		if sel, err := ParseSelector(input); err == nil {
+			t.Errorf("%q: expected error; got %#v", input, sel)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	var doc interface{}
+	dec := json.NewDecoder(strings.NewReader(`{
+		"memstats": {"HeapAlloc": 1024, "PauseNs": [500, 600]},
+		"cmdline.count": 3,
+		"servers": [{"name": "a", "load": 0.5}, {"name": "b", "load": 1.5}],
+		"enabled": true,
+		"version": "1.2"
+	}`))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		Selector string
+		Expected float64
+		Found    bool
+	}{
+		{"$.memstats.HeapAlloc", 1024, true},
+		{"memstats.PauseNs[1]", 600, true},
+		{`$["cmdline.count"]`, 3, true},
+		{"servers[1].load", 1.5, true},
+		{"enabled", 1, true},
+		{"version", 1.2, true},
+		// Missing fields and indexes out of range
+		{"$.memstats.StackInuse", 0, false},
+		{"memstats.PauseNs[2]", 0, false},
+		// Type mismatches
+		{"memstats[0]", 0, false},
+		{"servers.load", 0, false},
+		{"memstats.HeapAlloc.value", 0, false},
+	}
+	for _, test := range tests {
+		sel, err := ParseSelector(test.Selector)
+		if err != nil {
+			t.Fatalf("%s: %v", test.Selector, err)
+		}
+		v, found := sel.Select(doc)
+		if found != test.Found {
+			t.Errorf("%s: expected found %v; got %v", test.Selector, test.Found, found)
+			continue
+		}
+		if !found {
+			continue
+		}
+		f, ok := Number(v)
+		if !ok || f != test.Expected {
+			t.Errorf("%s: expected %v; got %v", test.Selector, test.Expected, v)
+		}
+	}
+
+	// Values that are no numbers
+	sel, _ := ParseSelector("servers[0].name")
+	if v, found := sel.Select(doc); !found {
+		t.Error("expected servers[0].name to be found")
+	} else if _, ok := Number(v); ok {
+		t.Errorf("expected %v not to be a number", v)
+	}
+	sel, _ = ParseSelector("memstats")
+	if v, _ := sel.Select(doc); v == nil {
+		t.Error("expected memstats to be found")
+	} else if _, ok := Number(v); ok {
+		t.Errorf("expected %v not to be a number", v)
+	}
+}
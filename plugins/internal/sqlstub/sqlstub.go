@@ -0,0 +1,110 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+// Package sqlstub provides a database/sql driver that answers queries
+// with canned results, for testing the plugins of SQL databases.
+package sqlstub
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Driver is the name the driver is registered with, for sql.Open.
+// The DSN selects the results, see SetResults.
+const Driver = "sqlstub"
+
+// Result is the result of a query: either rows or an error.
+type Result struct {
+	Columns []string
+	Rows    [][]driver.Value
+	Err     error
+}
+
+var (
+	mu sync.Mutex
+	// results are the results by DSN and by a substring of the query.
+	results = make(map[string]map[string]*Result)
+)
+
+func init() {
+	sql.Register(Driver, stubDriver{})
+}
+
+// SetResults sets the results returned for the DSN, keyed by a
+// substring of the query.
+func SetResults(dsn string, r map[string]*Result) {
+	mu.Lock()
+	results[dsn] = r
+	mu.Unlock()
+}
+
+type stubDriver struct{}
+
+func (stubDriver) Open(dsn string) (driver.Conn, error) {
+	return &stubConn{dsn: dsn}, nil
+}
+
+type stubConn struct {
+	dsn string
+}
+
+func (c *stubConn) Prepare(query string) (driver.Stmt, error) {
+	return &stubStmt{conn: c, query: query}, nil
+}
+
+func (c *stubConn) Close() error { return nil }
+
+func (c *stubConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions not supported")
+}
+
+type stubStmt struct {
+	conn  *stubConn
+	query string
+}
+
+func (s *stubStmt) Close() error  { return nil }
+func (s *stubStmt) NumInput() int { return -1 }
+
+func (s *stubStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("exec not supported")
+}
+
+func (s *stubStmt) Query(args []driver.Value) (driver.Rows, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	for substr, res := range results[s.conn.dsn] {
+		if strings.Contains(s.query, substr) {
+			if res.Err != nil {
+				return nil, res.Err
+			}
+			return &stubRows{columns: res.Columns, rows: res.Rows}, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected query %q", s.query)
+}
+
+type stubRows struct {
+	columns []string
+	rows    [][]driver.Value
+	i       int
+}
+
+func (r *stubRows) Columns() []string { return r.columns }
+func (r *stubRows) Close() error      { return nil }
+
+func (r *stubRows) Next(dest []driver.Value) error {
+	if r.i >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.i])
+	r.i++
+	return nil
+}
@@ -7,18 +7,28 @@
 package loadavg
 
 import (
+	"fmt"
 	"io/ioutil"
 	"strconv"
 	"strings"
+
+	"github.com/olivere/metronome/plugins"
 )
 
 func GetLoadAvg() (*LoadAvg, error) {
-	b, err := ioutil.ReadFile("/proc/loadavg")
+	b, err := ioutil.ReadFile(plugins.ProcPath("loadavg"))
 	if err != nil {
 		return nil, err
 	}
-	content := string(b)
+	return ParseLoadAvg(string(b))
+}
+
+// ParseLoadAvg parses the contents of /proc/loadavg.
+func ParseLoadAvg(content string) (*LoadAvg, error) {
 	values := strings.Fields(content)
+	if len(values) < 3 {
+		return nil, fmt.Errorf("invalid loadavg format %q", content)
+	}
 	loadavg := &LoadAvg{}
 	loadavg.Last1Min, _ = strconv.ParseFloat(values[0], 64)
 	loadavg.Last5Min, _ = strconv.ParseFloat(values[1], 64)
@@ -0,0 +1,36 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+// +build linux
+
+package loadavg
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+func TestGetLoadAvg(t *testing.T) {
+	defer func(root string) { plugins.ProcRoot = root }(plugins.ProcRoot)
+	plugins.ProcRoot = filepath.Join("testdata", "proc")
+
+	loadavg, err := GetLoadAvg()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := LoadAvg{Last1Min: 0.52, Last5Min: 0.38, Last15Min: 0.31}
+	if *loadavg != want {
+		t.Errorf("expected %+v; got %+v", want, *loadavg)
+	}
+}
+
+func TestParseLoadAvgInvalid(t *testing.T) {
+	for _, content := range []string{"", "0.52 0.38\n"} {
+		if _, err := ParseLoadAvg(content); err == nil {
+			t.Errorf("expected error for %q", content)
+		}
+	}
+}
@@ -4,9 +4,22 @@
 
 package mem
 
+// Mem is the memory usage of a machine. All sizes are in bytes.
 type Mem struct {
 	Total       int64
 	Free        int64
-	Used        int64
+	Used        int64 // memory not available for new applications
 	UsedPercent float64
+
+	Available int64 // estimate of memory available without swapping
+	Buffers   int64
+	Cached    int64
+	Slab      int64
+	Dirty     int64
+	Writeback int64
+	Shmem     int64
+
+	HugePagesTotal int64 // number of huge pages
+	HugePagesFree  int64 // number of free huge pages
+	HugePageSize   int64
 }
@@ -48,6 +48,7 @@ func GetMem() (*Mem, error) {
 	mem.Free, _ = strconv.ParseInt(free[0], 10, 64)
 	mem.Free = mem.Free * p
 
+	mem.Available = mem.Free
 	mem.Used = mem.Total - mem.Free
 
 	mem.UsedPercent = float64(mem.Total-mem.Free) / float64(mem.Total) * 100.0
@@ -7,41 +7,87 @@
 package mem
 
 import (
-	"io/ioutil"
+	"bufio"
+	"io"
+	"os"
 	"strconv"
 	"strings"
+
+	"github.com/olivere/metronome/plugins"
 )
 
+// GetMem returns Mem.
 func GetMem() (*Mem, error) {
-	data, err := ioutil.ReadFile("/proc/meminfo")
+	f, err := os.Open(plugins.ProcPath("meminfo"))
 	if err != nil {
 		return nil, err
 	}
-	lines := strings.Split(string(data), "\n")
+	defer f.Close()
+	return ParseMemInfo(f)
+}
 
+// ParseMemInfo parses the contents of /proc/meminfo.
+func ParseMemInfo(r io.Reader) (*Mem, error) {
 	mem := &Mem{}
-	for _, line := range lines {
-		values := strings.Split(line, ":")
+	hasAvailable := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		values := strings.Split(scanner.Text(), ":")
 		if len(values) != 2 {
 			continue
 		}
 		key := strings.TrimSpace(values[0])
 		value := strings.TrimSpace(values[1])
-		value = strings.Replace(value, " kB", "", -1)
+		unit := int64(1)
+		if strings.HasSuffix(value, " kB") {
+			value = strings.TrimSuffix(value, " kB")
+			unit = 1024
+		}
 
 		t, err := strconv.ParseInt(value, 10, 64)
 		if err != nil {
 			return nil, err
 		}
+		t *= unit
 		switch key {
 		case "MemTotal":
-			mem.Total = t * 1000
+			mem.Total = t
 		case "MemFree":
-			mem.Free = t * 1000
+			mem.Free = t
+		case "MemAvailable":
+			mem.Available = t
+			hasAvailable = true
+		case "Buffers":
+			mem.Buffers = t
+		case "Cached":
+			mem.Cached = t
+		case "Slab":
+			mem.Slab = t
+		case "Dirty":
+			mem.Dirty = t
+		case "Writeback":
+			mem.Writeback = t
+		case "Shmem":
+			mem.Shmem = t
+		case "HugePages_Total":
+			mem.HugePagesTotal = t
+		case "HugePages_Free":
+			mem.HugePagesFree = t
+		case "Hugepagesize":
+			mem.HugePageSize = t
 		}
 	}
-	mem.Used = mem.Total - mem.Free
-	mem.UsedPercent = float64(mem.Total-mem.Free) / float64(mem.Total) * 100.0
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !hasAvailable {
+		// Kernels before 3.14 don't report MemAvailable.
+		mem.Available = mem.Free + mem.Buffers + mem.Cached
+	}
+	mem.Used = mem.Total - mem.Available
+	if mem.Total > 0 {
+		mem.UsedPercent = float64(mem.Used) / float64(mem.Total) * 100.0
+	}
 
 	return mem, nil
 }
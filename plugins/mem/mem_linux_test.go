@@ -0,0 +1,109 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+// +build linux
+
+package mem
+
+import (
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+func TestParseMemInfo(t *testing.T) {
+	input := `MemTotal:        1000000 kB
+MemFree:          100000 kB
+MemAvailable:     600000 kB
+Buffers:           50000 kB
+Cached:           300000 kB
+Shmem:             20000 kB
+Slab:              40000 kB
+Dirty:               100 kB
+Writeback:             0 kB
+HugePages_Total:       4
+HugePages_Free:        2
+Hugepagesize:       2048 kB
+`
+	mem, err := ParseMemInfo(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Mem{
+		Total:          1000000 * 1024,
+		Free:           100000 * 1024,
+		Used:           400000 * 1024,
+		UsedPercent:    40,
+		Available:      600000 * 1024,
+		Buffers:        50000 * 1024,
+		Cached:         300000 * 1024,
+		Slab:           40000 * 1024,
+		Dirty:          100 * 1024,
+		Shmem:          20000 * 1024,
+		HugePagesTotal: 4, // a number of pages, not in kB
+		HugePagesFree:  2,
+		HugePageSize:   2048 * 1024,
+	}
+	if *mem != want {
+		t.Errorf("expected\n%+v\ngot\n%+v", want, *mem)
+	}
+}
+
+func TestParseMemInfoWithoutMemAvailable(t *testing.T) {
+	// Kernels before 3.14 have no MemAvailable
+	input := `MemTotal:        1000000 kB
+MemFree:          100000 kB
+Buffers:           50000 kB
+Cached:           300000 kB
+`
+	mem, err := ParseMemInfo(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(450000 * 1024); mem.Available != want {
+		t.Errorf("expected available of free + buffers + cached = %d; got %d", want, mem.Available)
+	}
+	if want := int64(550000 * 1024); mem.Used != want {
+		t.Errorf("expected used of %d; got %d", want, mem.Used)
+	}
+	if math.Abs(mem.UsedPercent-55) > 1e-9 {
+		t.Errorf("expected used percent of 55; got %v", mem.UsedPercent)
+	}
+}
+
+func TestParseMemInfoInvalid(t *testing.T) {
+	if _, err := ParseMemInfo(strings.NewReader("MemTotal: lots kB\n")); err == nil {
+		t.Error("expected error for invalid value")
+	}
+}
+
+func TestGetMem(t *testing.T) {
+	defer func(root string) { plugins.ProcRoot = root }(plugins.ProcRoot)
+	plugins.ProcRoot = filepath.Join("testdata", "proc")
+
+	mem, err := GetMem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Mem{
+		Total:        8048576 * 1024,
+		Free:         524288 * 1024,
+		Used:         4024288 * 1024,
+		UsedPercent:  50,
+		Available:    4024288 * 1024,
+		Buffers:      262144 * 1024,
+		Cached:       3145728 * 1024,
+		Slab:         393216 * 1024,
+		Dirty:        1024 * 1024,
+		Writeback:    64 * 1024,
+		Shmem:        131072 * 1024,
+		HugePageSize: 2048 * 1024,
+	}
+	if *mem != want {
+		t.Errorf("expected\n%+v\ngot\n%+v", want, *mem)
+	}
+}
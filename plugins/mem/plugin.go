@@ -16,6 +16,17 @@ type Plugin struct {
 	used        metrics.Gauge
 	usedPercent metrics.GaugeFloat64
 	free        metrics.Gauge
+	available   metrics.Gauge
+	buffers     metrics.Gauge
+	cached      metrics.Gauge
+	slab        metrics.Gauge
+	dirty       metrics.Gauge
+	writeback   metrics.Gauge
+	shmem       metrics.Gauge
+
+	hugePagesTotal metrics.Gauge
+	hugePagesFree  metrics.Gauge
+	hugePageSize   metrics.Gauge
 }
 
 // NewPlugin creates a Plugin that watches the memory usage of a machine.
@@ -29,6 +40,26 @@ func NewPlugin() (*Plugin, error) {
 	metrics.Register("mem.used", p.used)
 	p.usedPercent = metrics.NewGaugeFloat64()
 	metrics.Register("mem.usedpercent", p.usedPercent)
+	p.available = metrics.NewGauge()
+	metrics.Register("mem.available", p.available)
+	p.buffers = metrics.NewGauge()
+	metrics.Register("mem.buffers", p.buffers)
+	p.cached = metrics.NewGauge()
+	metrics.Register("mem.cached", p.cached)
+	p.slab = metrics.NewGauge()
+	metrics.Register("mem.slab", p.slab)
+	p.dirty = metrics.NewGauge()
+	metrics.Register("mem.dirty", p.dirty)
+	p.writeback = metrics.NewGauge()
+	metrics.Register("mem.writeback", p.writeback)
+	p.shmem = metrics.NewGauge()
+	metrics.Register("mem.shmem", p.shmem)
+	p.hugePagesTotal = metrics.NewGauge()
+	metrics.Register("mem.hugepages_total", p.hugePagesTotal)
+	p.hugePagesFree = metrics.NewGauge()
+	metrics.Register("mem.hugepages_free", p.hugePagesFree)
+	p.hugePageSize = metrics.NewGauge()
+	metrics.Register("mem.hugepage_size", p.hugePageSize)
 	return p, nil
 }
 
@@ -49,6 +80,16 @@ func (p *Plugin) Snapshot() (interface{}, error) {
 	p.free.Update(mem.Free)
 	p.used.Update(mem.Used)
 	p.usedPercent.Update(mem.UsedPercent)
+	p.available.Update(mem.Available)
+	p.buffers.Update(mem.Buffers)
+	p.cached.Update(mem.Cached)
+	p.slab.Update(mem.Slab)
+	p.dirty.Update(mem.Dirty)
+	p.writeback.Update(mem.Writeback)
+	p.shmem.Update(mem.Shmem)
+	p.hugePagesTotal.Update(mem.HugePagesTotal)
+	p.hugePagesFree.Update(mem.HugePagesFree)
+	p.hugePageSize.Update(mem.HugePageSize)
 
 	usedPercent := p.usedPercent.Value()
 	if math.IsNaN(usedPercent) {
@@ -61,5 +102,16 @@ func (p *Plugin) Snapshot() (interface{}, error) {
 		"used":         p.used.Value(),
 		"used_percent": usedPercent,
 		"free":         p.free.Value(),
+		"available":    p.available.Value(),
+		"buffers":      p.buffers.Value(),
+		"cached":       p.cached.Value(),
+		"slab":         p.slab.Value(),
+		"dirty":        p.dirty.Value(),
+		"writeback":    p.writeback.Value(),
+		"shmem":        p.shmem.Value(),
+
+		"hugepages_total": p.hugePagesTotal.Value(),
+		"hugepages_free":  p.hugePagesFree.Value(),
+		"hugepage_size":   p.hugePageSize.Value(),
 	}, nil
 }
@@ -0,0 +1,72 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package memcached
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stats is the output of the stats command, keyed by name,
+// e.g. "curr_items".
+type Stats map[string]string
+
+// Int returns the value of a stat as an integer, or 0.
+func (s Stats) Int(name string) int64 {
+	v, _ := strconv.ParseInt(s[name], 10, 64)
+	return v
+}
+
+// GetStats connects to the server at address and returns the output
+// of the stats command. The address is either host:port or the path of
+// a unix socket.
+func GetStats(address string, timeout time.Duration) (Stats, error) {
+	network := "tcp"
+	if strings.HasPrefix(address, "/") {
+		network = "unix"
+	}
+	conn, err := net.DialTimeout(network, address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+	if _, err := io.WriteString(conn, "stats\r\n"); err != nil {
+		return nil, err
+	}
+	return ParseStats(conn)
+}
+
+// ParseStats parses the output of the stats command, which consists of
+// lines like "STAT curr_items 42" and ends with "END".
+func ParseStats(r io.Reader) (Stats, error) {
+	stats := make(Stats)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "END" {
+			return stats, nil
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 3 && fields[0] == "STAT":
+			stats[fields[1]] = fields[2]
+		case len(fields) > 0 && strings.HasSuffix(fields[0], "ERROR"):
+			return nil, errors.New(line)
+		default:
+			return nil, fmt.Errorf("unexpected line %q", line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.ErrUnexpectedEOF
+}
@@ -0,0 +1,75 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package memcached
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseStats(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "stats.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	stats, err := ParseStats(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 25 {
+		t.Errorf("expected %d stats; got %d", 25, len(stats))
+	}
+	tests := map[string]int64{
+		"uptime":           86400,
+		"curr_connections": 10,
+		"get_hits":         90000,
+		"get_misses":       10000,
+		"bytes":            33554432,
+		"limit_maxbytes":   67108864,
+		"curr_items":       4200,
+		"evictions":        7,
+		"missing":          0,
+		"version":          0, // not an integer
+	}
+	for name, want := range tests {
+		if got := stats.Int(name); got != want {
+			t.Errorf("expected %s of %d; got %d", name, want, got)
+		}
+	}
+	if got := stats["version"]; got != "1.6.21" {
+		t.Errorf("expected version %q; got %q", "1.6.21", got)
+	}
+	if got := stats["rusage_user"]; got != "12.345678" {
+		t.Errorf("expected rusage_user %q; got %q", "12.345678", got)
+	}
+}
+
+func TestParseStatsInvalid(t *testing.T) {
+	tests := []struct {
+		Input string
+		Err   string
+	}{
+		{"", io.ErrUnexpectedEOF.Error()},
+		{"STAT pid 1234\r\n", io.ErrUnexpectedEOF.Error()},
+		{"ERROR\r\n", "ERROR"},
+		{"SERVER_ERROR out of memory\r\n", "SERVER_ERROR out of memory"},
+		{"STAT pid\r\nEND\r\n", `unexpected line "STAT pid"`},
+	}
+	for _, test := range tests {
+		_, err := ParseStats(strings.NewReader(test.Input))
+		if err == nil {
+			t.Errorf("expected error for %q", test.Input)
+			continue
+		}
+		if err.Error() != test.Err {
+			t.Errorf("expected error %q; got %q", test.Err, err)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package memcached
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+const (
+	defaultAddress = "localhost:11211"
+	defaultTimeout = 10 * time.Second
+)
+
+// Config is the configuration for the memcached plugin.
+type Config struct {
+	// Address of the server, either host:port or the path of a unix
+	// socket (default: localhost:11211).
+	Address string
+
+	// Timeout to connect and run stats (default: 10s).
+	Timeout time.Duration
+}
+
+// Plugin that watches a memcached server.
+//
+// Rates and the hit ratio are derived from the difference between two
+// snapshots, so the first snapshot reports zero rates and the hit
+// ratio since the start of the server.
+type Plugin struct {
+	name     string
+	address  string
+	timeout  time.Duration
+	last     Stats
+	lastTime time.Time
+}
+
+// NewPlugin initializes a new watcher for a memcached server.
+// Pass a name to differentiate between different servers.
+func NewPlugin(name string, config *Config) (*Plugin, error) {
+	if name == "" {
+		return nil, errors.New("no name specified")
+	}
+	if config == nil {
+		return nil, errors.New("no configuration specified")
+	}
+	plugin := &Plugin{
+		name:    name,
+		address: config.Address,
+		timeout: config.Timeout,
+	}
+	if plugin.address == "" {
+		plugin.address = defaultAddress
+	}
+	if plugin.timeout <= 0 {
+		plugin.timeout = defaultTimeout
+	}
+	return plugin, nil
+}
+
+// Name of the plugin. It is prefixed with "memcached." so that instances
+// do not collide with other plugins of the same name.
+func (p *Plugin) Name() string {
+	return "memcached." + p.name
+}
+
+// Snapshot returns the items, memory usage, connections and rates of
+// the server. If the server is unreachable, the health is reported as
+// critical along with the error.
+func (p *Plugin) Snapshot() (interface{}, error) {
+	stats, err := GetStats(p.address, p.timeout)
+	if err != nil {
+		return map[string]interface{}{
+			"health": plugins.HealthCritical,
+			"error":  err.Error(),
+		}, nil
+	}
+	now := time.Now()
+	secs := now.Sub(p.lastTime).Seconds()
+	prev := p.last
+	if prev == nil || stats.Int("uptime") < prev.Int("uptime") {
+		// First snapshot, or the server has been restarted
+		prev, secs = stats, 0
+	}
+	p.last = stats
+	p.lastTime = now
+
+	hits := stats.Int("get_hits") - prev.Int("get_hits")
+	misses := stats.Int("get_misses") - prev.Int("get_misses")
+	if secs == 0 {
+		hits, misses = stats.Int("get_hits"), stats.Int("get_misses")
+	}
+	var hitRatio float64
+	if hits+misses > 0 {
+		hitRatio = float64(hits) / float64(hits+misses)
+	}
+	var memoryPercent float64
+	if max := stats.Int("limit_maxbytes"); max > 0 {
+		memoryPercent = float64(stats.Int("bytes")) / float64(max) * 100.0
+	}
+
+	values := map[string]int64{
+		"curr_items":       stats.Int("curr_items"),
+		"bytes":            stats.Int("bytes"),
+		"limit_maxbytes":   stats.Int("limit_maxbytes"),
+		"curr_connections": stats.Int("curr_connections"),
+		"evictions":        stats.Int("evictions"),
+	}
+	rates := map[string]float64{
+		"hit_ratio":             hitRatio,
+		"memory_percent":        memoryPercent,
+		"gets_per_sec":          plugins.RateInt(stats.Int("cmd_get"), prev.Int("cmd_get"), secs),
+		"sets_per_sec":          plugins.RateInt(stats.Int("cmd_set"), prev.Int("cmd_set"), secs),
+		"evictions_per_sec":     plugins.RateInt(stats.Int("evictions"), prev.Int("evictions"), secs),
+		"connections_per_sec":   plugins.RateInt(stats.Int("total_connections"), prev.Int("total_connections"), secs),
+		"bytes_read_per_sec":    plugins.RateInt(stats.Int("bytes_read"), prev.Int("bytes_read"), secs),
+		"bytes_written_per_sec": plugins.RateInt(stats.Int("bytes_written"), prev.Int("bytes_written"), secs),
+	}
+
+	prefix := fmt.Sprintf("memcached.%s.", p.name)
+	data := map[string]interface{}{
+		"health":  plugins.HealthOK,
+		"uptime":  stats.Int("uptime"),
+		"version": stats["version"],
+	}
+	for key, value := range values {
+		metrics.GetOrRegisterGauge(prefix+key, nil).Update(value)
+		data[key] = value
+	}
+	for key, value := range rates {
+		metrics.GetOrRegisterGaugeFloat64(prefix+key, nil).Update(value)
+		data[key] = value
+	}
+
+	// Return data
+	return data, nil
+}
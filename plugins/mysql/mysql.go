@@ -0,0 +1,111 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+)
+
+// Status is the output of SHOW GLOBAL STATUS, keyed by variable name,
+// e.g. "Threads_connected".
+type Status map[string]string
+
+// Int returns the value of a status variable as an integer, or 0.
+func (s Status) Int(name string) int64 {
+	v, _ := strconv.ParseInt(s[name], 10, 64)
+	return v
+}
+
+// Replica is the state of replication on a replica, from
+// SHOW REPLICA STATUS.
+type Replica struct {
+	IORunning  bool
+	SQLRunning bool
+
+	// LagSeconds is Seconds_Behind_Source, or nil if unknown, e.g.
+	// because replication is stopped.
+	LagSeconds *int64
+}
+
+// GetStatus returns the global status variables.
+func GetStatus(ctx context.Context, db *sql.DB) (Status, error) {
+	rows, err := db.QueryContext(ctx, "SHOW GLOBAL STATUS")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	status := make(Status)
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		status[name] = value
+	}
+	return status, rows.Err()
+}
+
+// GetMaxConnections returns the max_connections setting.
+func GetMaxConnections(ctx context.Context, db *sql.DB) (int64, error) {
+	var max int64
+	err := db.QueryRowContext(ctx, "SELECT @@max_connections").Scan(&max)
+	return max, err
+}
+
+// GetReplica returns the state of replication, or nil if the server is
+// not a replica. It uses SHOW REPLICA STATUS and falls back to
+// SHOW SLAVE STATUS for servers before MySQL 8.0.22.
+func GetReplica(ctx context.Context, db *sql.DB) (*Replica, error) {
+	rows, err := db.QueryContext(ctx, "SHOW REPLICA STATUS")
+	if err != nil {
+		rows, err = db.QueryContext(ctx, "SHOW SLAVE STATUS")
+		if err != nil {
+			return nil, err
+		}
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+
+	// The columns differ between versions, so scan them all by name
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+	fields := make(map[string]sql.NullString)
+	for i, column := range columns {
+		fields[column] = values[i]
+	}
+	field := func(names ...string) sql.NullString {
+		for _, name := range names {
+			if v, found := fields[name]; found {
+				return v
+			}
+		}
+		return sql.NullString{}
+	}
+
+	r := &Replica{
+		IORunning:  field("Replica_IO_Running", "Slave_IO_Running").String == "Yes",
+		SQLRunning: field("Replica_SQL_Running", "Slave_SQL_Running").String == "Yes",
+	}
+	if lag := field("Seconds_Behind_Source", "Seconds_Behind_Master"); lag.Valid {
+		if n, err := strconv.ParseInt(lag.String, 10, 64); err == nil {
+			r.LagSeconds = &n
+		}
+	}
+	return r, rows.Err()
+}
@@ -0,0 +1,136 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/olivere/metronome/plugins/internal/sqlstub"
+)
+
+func openStub(t *testing.T, dsn string, results map[string]*sqlstub.Result) *sql.DB {
+	sqlstub.SetResults(dsn, results)
+	db, err := sql.Open(sqlstub.Driver, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// statusResult returns the result of SHOW GLOBAL STATUS with the
+// given variables.
+func statusResult(vars map[string]string) *sqlstub.Result {
+	r := &sqlstub.Result{Columns: []string{"Variable_name", "Value"}}
+	for name, value := range vars {
+		r.Rows = append(r.Rows, []driver.Value{name, value})
+	}
+	return r
+}
+
+func TestGetStatus(t *testing.T) {
+	db := openStub(t, "status", map[string]*sqlstub.Result{
+		"SHOW GLOBAL STATUS": statusResult(map[string]string{
+			"Threads_connected":              "12",
+			"Uptime":                         "3600",
+			"Innodb_buffer_pool_dump_status": "not started",
+		}),
+	})
+	defer db.Close()
+
+	status, err := GetStatus(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := status.Int("Threads_connected"); got != 12 {
+		t.Errorf("expected Threads_connected of 12; got %d", got)
+	}
+	if got := status.Int("Uptime"); got != 3600 {
+		t.Errorf("expected Uptime of 3600; got %d", got)
+	}
+	if got := status.Int("Innodb_buffer_pool_dump_status"); got != 0 {
+		t.Errorf("expected 0 for a non-numeric value; got %d", got)
+	}
+	if got := status.Int("Missing"); got != 0 {
+		t.Errorf("expected 0 for a missing value; got %d", got)
+	}
+}
+
+func TestGetReplica(t *testing.T) {
+	lag := int64(42)
+	tests := []struct {
+		Name    string
+		Results map[string]*sqlstub.Result
+		Want    *Replica
+	}{
+		{
+			Name: "replica status",
+			Results: map[string]*sqlstub.Result{
+				"SHOW REPLICA STATUS": {
+					Columns: []string{"Replica_IO_State", "Replica_IO_Running", "Replica_SQL_Running", "Seconds_Behind_Source"},
+					Rows:    [][]driver.Value{{"Waiting for source to send event", "Yes", "Yes", "42"}},
+				},
+			},
+			Want: &Replica{IORunning: true, SQLRunning: true, LagSeconds: &lag},
+		},
+		{
+			Name: "slave status before 8.0.22",
+			Results: map[string]*sqlstub.Result{
+				"SHOW REPLICA STATUS": {Err: errors.New("You have an error in your SQL syntax")},
+				"SHOW SLAVE STATUS": {
+					Columns: []string{"Slave_IO_State", "Slave_IO_Running", "Slave_SQL_Running", "Seconds_Behind_Master"},
+					Rows:    [][]driver.Value{{"Waiting for master to send event", "Yes", "Yes", "42"}},
+				},
+			},
+			Want: &Replica{IORunning: true, SQLRunning: true, LagSeconds: &lag},
+		},
+		{
+			Name: "stopped",
+			Results: map[string]*sqlstub.Result{
+				"SHOW REPLICA STATUS": {
+					Columns: []string{"Replica_IO_Running", "Replica_SQL_Running", "Seconds_Behind_Source"},
+					Rows:    [][]driver.Value{{"Yes", "No", nil}},
+				},
+			},
+			Want: &Replica{IORunning: true, SQLRunning: false},
+		},
+		{
+			Name: "not a replica",
+			Results: map[string]*sqlstub.Result{
+				"SHOW REPLICA STATUS": {Columns: []string{"Replica_IO_Running"}},
+			},
+			Want: nil,
+		},
+	}
+	for i, test := range tests {
+		db := openStub(t, test.Name, test.Results)
+		got, err := GetReplica(context.Background(), db)
+		db.Close()
+		if err != nil {
+			t.Errorf("#%d %s: %v", i, test.Name, err)
+			continue
+		}
+		if test.Want == nil || got == nil {
+			if test.Want != got {
+				t.Errorf("#%d %s: expected %+v; got %+v", i, test.Name, test.Want, got)
+			}
+			continue
+		}
+		if got.IORunning != test.Want.IORunning || got.SQLRunning != test.Want.SQLRunning {
+			t.Errorf("#%d %s: expected %+v; got %+v", i, test.Name, test.Want, got)
+		}
+		switch {
+		case test.Want.LagSeconds == nil && got.LagSeconds != nil:
+			t.Errorf("#%d %s: expected no lag; got %d", i, test.Name, *got.LagSeconds)
+		case test.Want.LagSeconds != nil && got.LagSeconds == nil:
+			t.Errorf("#%d %s: expected lag of %d; got none", i, test.Name, *test.Want.LagSeconds)
+		case test.Want.LagSeconds != nil && *got.LagSeconds != *test.Want.LagSeconds:
+			t.Errorf("#%d %s: expected lag of %d; got %d", i, test.Name, *test.Want.LagSeconds, *got.LagSeconds)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+const (
+	defaultDriver  = "mysql"
+	defaultTimeout = 10 * time.Second
+)
+
+// Config is the configuration for the MySQL plugin.
+type Config struct {
+	// Driver is the name of the database/sql driver (default: mysql).
+	// The driver must be registered by the program, e.g. by importing
+	// github.com/go-sql-driver/mysql.
+	Driver string
+
+	// DSN is the data source name, e.g. "metronome:secret@tcp(localhost:3306)/".
+	DSN string
+
+	// Timeout for gathering the stats (default: 10s).
+	Timeout time.Duration
+}
+
+// Plugin that watches a MySQL server.
+//
+// Rates and the buffer pool hit ratio are derived from the difference
+// between two snapshots, so the first snapshot reports zero rates and
+// the hit ratio since the start of the server.
+type Plugin struct {
+	name     string
+	db       *sql.DB
+	timeout  time.Duration
+	last     Status
+	lastTime time.Time
+}
+
+// NewPlugin initializes a new watcher for a MySQL server.
+// Pass a name to differentiate between different servers. The server
+// is not contacted before the first snapshot.
+func NewPlugin(name string, config *Config) (*Plugin, error) {
+	if name == "" {
+		return nil, errors.New("no name specified")
+	}
+	if config == nil {
+		return nil, errors.New("no configuration specified")
+	}
+	if config.DSN == "" {
+		return nil, errors.New("no dsn specified")
+	}
+	driver := config.Driver
+	if driver == "" {
+		driver = defaultDriver
+	}
+	db, err := sql.Open(driver, config.DSN)
+	if err != nil {
+		return nil, err
+	}
+	// A single connection is enough to gather the stats
+	db.SetMaxOpenConns(1)
+
+	plugin := &Plugin{
+		name:    name,
+		db:      db,
+		timeout: config.Timeout,
+	}
+	if plugin.timeout <= 0 {
+		plugin.timeout = defaultTimeout
+	}
+	return plugin, nil
+}
+
+// Name of the plugin. It is prefixed with "mysql." so that instances
+// do not collide with other plugins of the same name.
+func (p *Plugin) Name() string {
+	return "mysql." + p.name
+}
+
+// Close closes the connection to the server.
+func (p *Plugin) Close() error {
+	return p.db.Close()
+}
+
+// Snapshot returns the threads, queries, InnoDB buffer pool usage and
+// replication state of the server. If the server is unreachable or
+// replication has stopped, the health is reported as critical.
+func (p *Plugin) Snapshot() (interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	status, err := GetStatus(ctx, p.db)
+	if err != nil {
+		return unhealthy(err), nil
+	}
+	maxConns, err := GetMaxConnections(ctx, p.db)
+	if err != nil {
+		return unhealthy(err), nil
+	}
+	replica, err := GetReplica(ctx, p.db)
+	if err != nil {
+		return unhealthy(err), nil
+	}
+	now := time.Now()
+	secs := now.Sub(p.lastTime).Seconds()
+	prev := p.last
+	if prev == nil || status.Int("Uptime") < prev.Int("Uptime") {
+		// First snapshot, or the server has been restarted
+		prev, secs = status, 0
+	}
+	p.last = status
+	p.lastTime = now
+
+	prefix := fmt.Sprintf("mysql.%s.", p.name)
+	data := make(map[string]interface{})
+	gauge := func(key string, value int64) {
+		metrics.GetOrRegisterGauge(prefix+key, nil).Update(value)
+		data[key] = value
+	}
+	gaugeFloat64 := func(key string, value float64) {
+		metrics.GetOrRegisterGaugeFloat64(prefix+key, nil).Update(value)
+		data[key] = value
+	}
+
+	// Threads and connections
+	gauge("threads_connected", status.Int("Threads_connected"))
+	gauge("threads_running", status.Int("Threads_running"))
+	gauge("max_connections", maxConns)
+	if maxConns > 0 {
+		gaugeFloat64("connections_percent", float64(status.Int("Threads_connected"))/float64(maxConns)*100.0)
+	}
+	gaugeFloat64("connections_per_sec", plugins.RateInt(status.Int("Connections"), prev.Int("Connections"), secs))
+	gaugeFloat64("aborted_connects_per_sec", plugins.RateInt(status.Int("Aborted_connects"), prev.Int("Aborted_connects"), secs))
+
+	// Queries
+	gaugeFloat64("queries_per_sec", plugins.RateInt(status.Int("Questions"), prev.Int("Questions"), secs))
+	gauge("slow_queries", status.Int("Slow_queries"))
+	gaugeFloat64("slow_queries_per_sec", plugins.RateInt(status.Int("Slow_queries"), prev.Int("Slow_queries"), secs))
+
+	// InnoDB buffer pool
+	total := status.Int("Innodb_buffer_pool_pages_total")
+	free := status.Int("Innodb_buffer_pool_pages_free")
+	gauge("innodb_buffer_pool_pages_total", total)
+	gauge("innodb_buffer_pool_pages_free", free)
+	gauge("innodb_buffer_pool_pages_dirty", status.Int("Innodb_buffer_pool_pages_dirty"))
+	gauge("innodb_buffer_pool_bytes_data", status.Int("Innodb_buffer_pool_bytes_data"))
+	if total > 0 {
+		gaugeFloat64("innodb_buffer_pool_percent", float64(total-free)/float64(total)*100.0)
+	}
+	requests := status.Int("Innodb_buffer_pool_read_requests") - prev.Int("Innodb_buffer_pool_read_requests")
+	reads := status.Int("Innodb_buffer_pool_reads") - prev.Int("Innodb_buffer_pool_reads")
+	if secs == 0 {
+		requests, reads = status.Int("Innodb_buffer_pool_read_requests"), status.Int("Innodb_buffer_pool_reads")
+	}
+	var hitRatio float64
+	if requests > 0 && reads <= requests {
+		// Reads are the requests that could not be satisfied from the pool
+		hitRatio = 1 - float64(reads)/float64(requests)
+	}
+	gaugeFloat64("innodb_buffer_pool_hit_ratio", hitRatio)
+
+	// Replication
+	health := plugins.HealthOK
+	data["replica"] = replica != nil
+	if replica != nil {
+		data["replica_io_running"] = replica.IORunning
+		data["replica_sql_running"] = replica.SQLRunning
+		if !replica.IORunning || !replica.SQLRunning {
+			health = plugins.HealthCritical
+		}
+		if replica.LagSeconds != nil {
+			gauge("replica_lag_seconds", *replica.LagSeconds)
+		}
+	}
+	data["health"] = health
+	data["uptime"] = status.Int("Uptime")
+
+	// Return data
+	return data, nil
+}
+
+// unhealthy returns the snapshot of a server that cannot be watched.
+func unhealthy(err error) map[string]interface{} {
+	return map[string]interface{}{
+		"health": plugins.HealthCritical,
+		"error":  err.Error(),
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package mysql
+
+import (
+	"database/sql/driver"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/olivere/metronome/plugins"
+	"github.com/olivere/metronome/plugins/internal/sqlstub"
+)
+
+func TestSnapshot(t *testing.T) {
+	vars := map[string]string{
+		"Uptime":                           "100",
+		"Threads_connected":                "15",
+		"Questions":                        "1000",
+		"Innodb_buffer_pool_pages_total":   "1000",
+		"Innodb_buffer_pool_pages_free":    "250",
+		"Innodb_buffer_pool_read_requests": "1000",
+		"Innodb_buffer_pool_reads":         "10",
+	}
+	results := map[string]*sqlstub.Result{
+		"SHOW GLOBAL STATUS": statusResult(vars),
+		"max_connections": {
+			Columns: []string{"@@max_connections"},
+			Rows:    [][]driver.Value{{int64(150)}},
+		},
+		"SHOW REPLICA STATUS": {
+			Columns: []string{"Replica_IO_Running", "Replica_SQL_Running", "Seconds_Behind_Source"},
+			Rows:    [][]driver.Value{{"Yes", "Yes", "3"}},
+		},
+	}
+	sqlstub.SetResults("snapshot", results)
+	p, err := NewPlugin("snapshot", &Config{Driver: sqlstub.Driver, DSN: "snapshot"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// The first snapshot reports zero rates and the hit ratio since the
+	// start of the server.
+	data, err := p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := data.(map[string]interface{})
+	tests := map[string]interface{}{
+		"health":                       plugins.HealthOK,
+		"replica":                      true,
+		"replica_lag_seconds":          int64(3),
+		"connections_percent":          10.0,
+		"queries_per_sec":              0.0,
+		"innodb_buffer_pool_percent":   75.0,
+		"innodb_buffer_pool_hit_ratio": 0.99,
+	}
+	for key, want := range tests {
+		if got := m[key]; got != want {
+			t.Errorf("expected %s of %v; got %v", key, want, got)
+		}
+	}
+
+	// The second snapshot reports the difference
+	vars["Uptime"] = "110"
+	vars["Questions"] = "1500"
+	vars["Innodb_buffer_pool_read_requests"] = "2000"
+	vars["Innodb_buffer_pool_reads"] = "60"
+	results["SHOW GLOBAL STATUS"] = statusResult(vars)
+	p.lastTime = p.lastTime.Add(-10 * time.Second)
+	data, err = p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = data.(map[string]interface{})
+	floats := map[string]float64{
+		"queries_per_sec":              50,   // 500 questions / 10s
+		"innodb_buffer_pool_hit_ratio": 0.95, // 1 - 50 reads / 1000 requests
+	}
+	for key, want := range floats {
+		if got := m[key].(float64); math.Abs(got-want) > want*0.01 {
+			t.Errorf("expected %s of %v; got %v", key, want, got)
+		}
+	}
+
+	// After a restart, the counters start over
+	vars["Uptime"] = "5"
+	vars["Questions"] = "10"
+	results["SHOW GLOBAL STATUS"] = statusResult(vars)
+	data, err = p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = data.(map[string]interface{})
+	if got := m["queries_per_sec"]; got != 0.0 {
+		t.Errorf("expected queries_per_sec of 0 after restart; got %v", got)
+	}
+}
+
+func TestSnapshotReplicationStopped(t *testing.T) {
+	sqlstub.SetResults("stopped", map[string]*sqlstub.Result{
+		"SHOW GLOBAL STATUS": statusResult(map[string]string{"Uptime": "100"}),
+		"max_connections": {
+			Columns: []string{"@@max_connections"},
+			Rows:    [][]driver.Value{{int64(150)}},
+		},
+		"SHOW REPLICA STATUS": {Err: errors.New("You have an error in your SQL syntax")},
+		"SHOW SLAVE STATUS": {
+			Columns: []string{"Slave_IO_Running", "Slave_SQL_Running", "Seconds_Behind_Master"},
+			Rows:    [][]driver.Value{{"No", "Yes", nil}},
+		},
+	})
+	p, err := NewPlugin("stopped", &Config{Driver: sqlstub.Driver, DSN: "stopped"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	data, err := p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := data.(map[string]interface{})
+	if got := m["health"]; got != plugins.HealthCritical {
+		t.Errorf("expected critical health; got %v", got)
+	}
+	if got := m["replica_io_running"]; got != false {
+		t.Errorf("expected replica_io_running of false; got %v", got)
+	}
+	if _, found := m["replica_lag_seconds"]; found {
+		t.Error("expected no replica_lag_seconds when the lag is unknown")
+	}
+}
+
+func TestSnapshotDown(t *testing.T) {
+	sqlstub.SetResults("down", map[string]*sqlstub.Result{
+		"SHOW GLOBAL STATUS": {Err: errors.New("connection refused")},
+	})
+	p, err := NewPlugin("down", &Config{Driver: sqlstub.Driver, DSN: "down"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	data, err := p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := data.(map[string]interface{})
+	if got := m["health"]; got != plugins.HealthCritical {
+		t.Errorf("expected critical health; got %v", got)
+	}
+	if got := m["error"]; got != "connection refused" {
+		t.Errorf("expected error; got %v", got)
+	}
+}
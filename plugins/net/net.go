@@ -0,0 +1,24 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package net
+
+// Stat is the traffic of a network interface since boot.
+type Stat struct {
+	Name      string
+	RxBytes   uint64
+	RxPackets uint64
+	RxErrors  uint64
+	RxDropped uint64
+	TxBytes   uint64
+	TxPackets uint64
+	TxErrors  uint64
+	TxDropped uint64
+}
+
+// Link is the state of the link of a network interface.
+type Link struct {
+	Speed     int64  // in Mbit/s, or -1 if unknown
+	OperState string // e.g. "up", "down" or "unknown"
+}
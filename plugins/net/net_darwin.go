@@ -0,0 +1,19 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+// +build darwin
+
+package net
+
+import "errors"
+
+// GetStats is not supported on Darwin.
+func GetStats() ([]Stat, error) {
+	return nil, errors.New("net: not supported on darwin")
+}
+
+// GetLink is not supported on Darwin.
+func GetLink(name string) (*Link, error) {
+	return nil, errors.New("net: not supported on darwin")
+}
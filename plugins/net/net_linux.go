@@ -0,0 +1,93 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+// +build linux
+
+package net
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+// GetStats returns the traffic of all network interfaces from
+// /proc/self/net/dev. If a host root is configured, it is read from
+// /proc/1/net/dev instead, i.e. of the network namespace of init, so that
+// the host's interfaces are reported when running in a container.
+func GetStats() ([]Stat, error) {
+	f, err := os.Open(plugins.NamespacePath("net", "dev"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseNetDev(f)
+}
+
+// ParseNetDev parses the contents of /proc/net/dev.
+func ParseNetDev(r io.Reader) ([]Stat, error) {
+	var stats []Stat
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		i := strings.Index(line, ":")
+		if i < 0 {
+			// Header lines
+			continue
+		}
+		fields := strings.Fields(line[i+1:])
+		if len(fields) < 16 {
+			return nil, fmt.Errorf("invalid net/dev line %q", line)
+		}
+		values := make([]uint64, 16)
+		for j := range values {
+			v, err := strconv.ParseUint(fields[j], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			values[j] = v
+		}
+		stats = append(stats, Stat{
+			Name:      strings.TrimSpace(line[:i]),
+			RxBytes:   values[0],
+			RxPackets: values[1],
+			RxErrors:  values[2],
+			RxDropped: values[3],
+			TxBytes:   values[8],
+			TxPackets: values[9],
+			TxErrors:  values[10],
+			TxDropped: values[11],
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetLink returns the link state of a network interface from
+// /sys/class/net/<name>.
+func GetLink(name string) (*Link, error) {
+	dir := plugins.SysPath("class", "net", name)
+	state, err := ioutil.ReadFile(filepath.Join(dir, "operstate"))
+	if err != nil {
+		return nil, err
+	}
+	link := &Link{Speed: -1, OperState: strings.TrimSpace(string(state))}
+	// Reading speed fails with EINVAL for interfaces that are down
+	// or don't have a speed, like virtual ones.
+	if speed, err := ioutil.ReadFile(filepath.Join(dir, "speed")); err == nil {
+		if v, err := strconv.ParseInt(strings.TrimSpace(string(speed)), 10, 64); err == nil {
+			link.Speed = v
+		}
+	}
+	return link, nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+// +build linux
+
+package net
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+func TestGetStats(t *testing.T) {
+	defer func(root string) { plugins.ProcRoot = root }(plugins.ProcRoot)
+	plugins.ProcRoot = "testdata/proc"
+
+	stats, err := GetStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Stat{
+		{Name: "lo", RxBytes: 1000, RxPackets: 10, TxBytes: 1000, TxPackets: 10},
+		{
+			Name:    "eth0",
+			RxBytes: 2000000, RxPackets: 15000, RxErrors: 1, RxDropped: 2,
+			TxBytes: 500000, TxPackets: 4000, TxErrors: 3, TxDropped: 4,
+		},
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("expected\n%+v\ngot\n%+v", want, stats)
+	}
+}
+
+func TestGetLink(t *testing.T) {
+	defer func(root string) { plugins.SysRoot = root }(plugins.SysRoot)
+	plugins.SysRoot = "testdata/sys"
+
+	tests := []struct {
+		name string
+		want Link
+	}{
+		{name: "eth0", want: Link{Speed: 1000, OperState: "up"}},
+		{name: "lo", want: Link{Speed: -1, OperState: "unknown"}},
+	}
+	for _, tt := range tests {
+		link, err := GetLink(tt.name)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if *link != tt.want {
+			t.Errorf("%s: expected %+v; got %+v", tt.name, tt.want, *link)
+		}
+	}
+
+	if _, err := GetLink("eth1"); err == nil {
+		t.Error("expected error for missing interface")
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package net
+
+import (
+	"errors"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+var (
+	// DefaultExcludeInterfaces are the interfaces that are skipped unless
+	// Config.ExcludeInterfaces is set.
+	DefaultExcludeInterfaces = []string{"lo", "veth*"}
+)
+
+// Config is the configuration for the net plugin. Interfaces are
+// selected by patterns as used by path.Match, e.g. "eth*".
+type Config struct {
+	IncludeInterfaces []string
+	// ExcludeInterfaces defaults to DefaultExcludeInterfaces if nil.
+	ExcludeInterfaces []string
+}
+
+// Plugin watches the network interfaces of a machine.
+//
+// Rates are derived from the difference between two snapshots, so the
+// first snapshot reports zero rates.
+type Plugin struct {
+	interfaces *plugins.Filter
+	last       map[string]Stat
+	lastTime   time.Time
+}
+
+// NewPlugin initializes a new Plugin to watch the network interfaces.
+func NewPlugin(config *Config) (*Plugin, error) {
+	if config == nil {
+		return nil, errors.New("no configuration specified")
+	}
+	excludeInterfaces := config.ExcludeInterfaces
+	if excludeInterfaces == nil {
+		excludeInterfaces = DefaultExcludeInterfaces
+	}
+	interfaces, err := plugins.NewFilter(config.IncludeInterfaces, excludeInterfaces)
+	if err != nil {
+		return nil, err
+	}
+	return &Plugin{interfaces: interfaces}, nil
+}
+
+// Name of the plugin.
+func (p *Plugin) Name() string {
+	return "net"
+}
+
+// Snapshot returns the traffic of all selected interfaces since the last
+// snapshot, keyed by interface name.
+func (p *Plugin) Snapshot() (interface{}, error) {
+	stats, err := GetStats()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	secs := now.Sub(p.lastTime).Seconds()
+
+	data := make(map[string]interface{})
+	cur := make(map[string]Stat)
+	for _, s := range stats {
+		if !p.interfaces.Match(s.Name) {
+			continue
+		}
+		cur[s.Name] = s
+
+		var iface map[string]interface{}
+		if prev, found := p.last[s.Name]; found {
+			iface = p.iface(s, prev, secs)
+		} else {
+			// Report zeros until we have two samples.
+			iface = p.iface(s, s, 0)
+		}
+		if link, err := GetLink(s.Name); err == nil {
+			iface["speed"] = link.Speed
+			iface["operstate"] = link.OperState
+		}
+		data[s.Name] = iface
+	}
+	p.last = cur
+	p.lastTime = now
+
+	// Return data
+	return data, nil
+}
+
+// iface computes the rates of an interface from two samples taken secs
+// seconds apart, and updates the registered metrics.
+func (p *Plugin) iface(cur, prev Stat, secs float64) map[string]interface{} {
+	values := map[string]float64{
+		"rx_bytes_per_sec":   plugins.Rate(cur.RxBytes, prev.RxBytes, secs),
+		"rx_packets_per_sec": plugins.Rate(cur.RxPackets, prev.RxPackets, secs),
+		"rx_errors_per_sec":  plugins.Rate(cur.RxErrors, prev.RxErrors, secs),
+		"rx_dropped_per_sec": plugins.Rate(cur.RxDropped, prev.RxDropped, secs),
+		"tx_bytes_per_sec":   plugins.Rate(cur.TxBytes, prev.TxBytes, secs),
+		"tx_packets_per_sec": plugins.Rate(cur.TxPackets, prev.TxPackets, secs),
+		"tx_errors_per_sec":  plugins.Rate(cur.TxErrors, prev.TxErrors, secs),
+		"tx_dropped_per_sec": plugins.Rate(cur.TxDropped, prev.TxDropped, secs),
+	}
+
+	data := make(map[string]interface{})
+	for key, value := range values {
+		metrics.GetOrRegisterGaugeFloat64("net."+cur.Name+"."+key, nil).Update(value)
+		data[key] = value
+	}
+	return data
+}
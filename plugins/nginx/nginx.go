@@ -0,0 +1,90 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package nginx
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// StubStatus is the output of the stub_status module of nginx.
+type StubStatus struct {
+	Active   int64 // active client connections, including waiting
+	Accepts  int64 // accepted client connections, cumulative
+	Handled  int64 // handled client connections, cumulative
+	Requests int64 // client requests, cumulative
+	Reading  int64 // connections where nginx is reading the request header
+	Writing  int64 // connections where nginx is writing the response
+	Waiting  int64 // idle client connections waiting for a request
+}
+
+// GetStubStatus fetches and parses the stub_status page at url.
+func GetStubStatus(client *http.Client, url string) (*StubStatus, error) {
+	res, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", res.Status)
+	}
+	return ParseStubStatus(res.Body)
+}
+
+// ParseStubStatus parses the output of the stub_status module:
+//
+//	Active connections: 291
+//	server accepts handled requests
+//	 16630948 16630948 31070465
+//	Reading: 6 Writing: 179 Waiting: 106
+func ParseStubStatus(r io.Reader) (*StubStatus, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimSpace(scanner.Text()))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) < 4 {
+		return nil, fmt.Errorf("invalid stub_status format: %d lines", len(lines))
+	}
+
+	s := &StubStatus{}
+	var err error
+	if s.Active, err = parseValue(lines[0], "Active connections:"); err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(lines[2])
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("invalid stub_status counters %q", lines[2])
+	}
+	for i, v := range []*int64{&s.Accepts, &s.Handled, &s.Requests} {
+		if *v, err = strconv.ParseInt(fields[i], 10, 64); err != nil {
+			return nil, err
+		}
+	}
+	fields = strings.Fields(lines[3])
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("invalid stub_status connections %q", lines[3])
+	}
+	for i, v := range []*int64{&s.Reading, &s.Writing, &s.Waiting} {
+		if *v, err = strconv.ParseInt(fields[2*i+1], 10, 64); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func parseValue(line, prefix string) (int64, error) {
+	if !strings.HasPrefix(line, prefix) {
+		return 0, fmt.Errorf("invalid stub_status line %q", line)
+	}
+	return strconv.ParseInt(strings.TrimSpace(line[len(prefix):]), 10, 64)
+}
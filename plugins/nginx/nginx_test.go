@@ -0,0 +1,51 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package nginx
+
+import (
+	"strings"
+	"testing"
+)
+
+const stubStatus = `Active connections: 291
+server accepts handled requests
+ 16630948 16630940 31070465
+Reading: 6 Writing: 179 Waiting: 106
+`
+
+func TestParseStubStatus(t *testing.T) {
+	s, err := ParseStubStatus(strings.NewReader(stubStatus))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := StubStatus{
+		Active:   291,
+		Accepts:  16630948,
+		Handled:  16630940,
+		Requests: 31070465,
+		Reading:  6,
+		Writing:  179,
+		Waiting:  106,
+	}
+	if *s != want {
+		t.Errorf("expected %+v; got %+v", want, *s)
+	}
+}
+
+func TestParseStubStatusInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"Active connections: 291\n",
+		"<html><body>Welcome to nginx!</body></html>\n\n\n\n",
+		"Active connections: x\nserver accepts handled requests\n 1 1 1\nReading: 0 Writing: 1 Waiting: 0\n",
+		"Active connections: 1\nserver accepts handled requests\n 1 1\nReading: 0 Writing: 1 Waiting: 0\n",
+		"Active connections: 1\nserver accepts handled requests\n 1 1 1\nReading: 0 Writing: 1\n",
+	}
+	for _, input := range tests {
+		if _, err := ParseStubStatus(strings.NewReader(input)); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package nginx
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+const (
+	defaultURL     = "http://localhost/nginx_status"
+	defaultTimeout = 10 * time.Second
+)
+
+// Config is the configuration for the nginx plugin.
+type Config struct {
+	// URLs of the stub_status pages to watch
+	// (default: http://localhost/nginx_status).
+	URLs []string
+
+	// Timeout for fetching a status page (default: 10s).
+	Timeout time.Duration
+}
+
+// Plugin watches one or more nginx servers via their stub_status page.
+//
+// Rates are derived from the difference between two snapshots, so the
+// first snapshot reports zero rates.
+type Plugin struct {
+	urls     []string
+	client   *http.Client
+	last     map[string]*StubStatus
+	lastTime time.Time
+}
+
+// NewPlugin initializes a new Plugin to watch nginx servers.
+func NewPlugin(config *Config) (*Plugin, error) {
+	if config == nil {
+		return nil, errors.New("no configuration specified")
+	}
+	urls := config.URLs
+	if len(urls) == 0 {
+		urls = []string{defaultURL}
+	}
+	for _, u := range urls {
+		if _, err := url.Parse(u); err != nil {
+			return nil, err
+		}
+	}
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Plugin{
+		urls:   urls,
+		client: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Name of the plugin.
+func (p *Plugin) Name() string {
+	return "nginx"
+}
+
+// Snapshot returns the connections and request rates of all servers,
+// keyed by the URL of their status page. Servers that cannot be
+// reached are reported with critical health and the error.
+func (p *Plugin) Snapshot() (interface{}, error) {
+	now := time.Now()
+	secs := now.Sub(p.lastTime).Seconds()
+
+	data := make(map[string]interface{})
+	cur := make(map[string]*StubStatus)
+	for _, u := range p.urls {
+		s, err := GetStubStatus(p.client, u)
+		if err != nil {
+			data[u] = map[string]interface{}{
+				"health": plugins.HealthCritical,
+				"error":  err.Error(),
+			}
+			continue
+		}
+		cur[u] = s
+		if prev, found := p.last[u]; found {
+			data[u] = p.server(u, s, prev, secs)
+		} else {
+			data[u] = p.server(u, s, s, 0)
+		}
+	}
+	p.last = cur
+	p.lastTime = now
+
+	// Return data
+	return data, nil
+}
+
+// server computes the metrics of a server from two samples taken secs
+// seconds apart, and updates the registered metrics.
+func (p *Plugin) server(rawurl string, cur, prev *StubStatus, secs float64) map[string]interface{} {
+	prefix := "nginx." + metricName(rawurl) + "."
+
+	values := map[string]int64{
+		"active":  cur.Active,
+		"reading": cur.Reading,
+		"writing": cur.Writing,
+		"waiting": cur.Waiting,
+	}
+	rates := map[string]float64{
+		"requests_per_sec": plugins.RateInt(cur.Requests, prev.Requests, secs),
+		"accepts_per_sec":  plugins.RateInt(cur.Accepts, prev.Accepts, secs),
+		// Connections dropped e.g. because of worker_connections limits
+		"dropped_per_sec": plugins.RateInt(cur.Accepts-cur.Handled, prev.Accepts-prev.Handled, secs),
+	}
+
+	data := map[string]interface{}{"health": plugins.HealthOK}
+	for key, value := range values {
+		metrics.GetOrRegisterGauge(prefix+key, nil).Update(value)
+		data[key] = value
+	}
+	for key, value := range rates {
+		metrics.GetOrRegisterGaugeFloat64(prefix+key, nil).Update(value)
+		data[key] = value
+	}
+	return data
+}
+
+// metricName returns the name to use in metrics for a status page,
+// i.e. its host and port.
+func metricName(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil || u.Host == "" {
+		return rawurl
+	}
+	return u.Host
+}
@@ -0,0 +1,71 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package nginx
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+func TestSnapshot(t *testing.T) {
+	var requests int64 = 1000
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/nginx_status" {
+			http.NotFound(w, r)
+			return
+		}
+		n := atomic.AddInt64(&requests, 500)
+		fmt.Fprintf(w, "Active connections: 3\nserver accepts handled requests\n %d %d %d\nReading: 0 Writing: 1 Waiting: 2\n", n, n, n)
+	}))
+	defer srv.Close()
+
+	statusURL := srv.URL + "/nginx_status"
+	missingURL := srv.URL + "/missing"
+	p, err := NewPlugin(&Config{URLs: []string{statusURL, missingURL}, Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := data.(map[string]interface{})[statusURL].(map[string]interface{})
+	if got := server["health"]; got != plugins.HealthOK {
+		t.Errorf("expected health ok; got %v", got)
+	}
+	if got := server["active"]; got != int64(3) {
+		t.Errorf("expected 3 active connections; got %v", got)
+	}
+	if got := server["requests_per_sec"]; got != 0.0 {
+		t.Errorf("expected requests_per_sec of 0 on first snapshot; got %v", got)
+	}
+	missing := data.(map[string]interface{})[missingURL].(map[string]interface{})
+	if got := missing["health"]; got != plugins.HealthCritical {
+		t.Errorf("expected critical health for missing page; got %v", got)
+	}
+	if _, found := missing["error"]; !found {
+		t.Error("expected error for missing page")
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	data, err = p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server = data.(map[string]interface{})[statusURL].(map[string]interface{})
+	if got := server["requests_per_sec"].(float64); got <= 0 {
+		t.Errorf("expected requests_per_sec > 0; got %v", got)
+	}
+	if got := server["dropped_per_sec"]; got != 0.0 {
+		t.Errorf("expected dropped_per_sec of 0; got %v", got)
+	}
+}
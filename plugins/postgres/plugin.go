@@ -0,0 +1,234 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+const (
+	defaultDriver  = "postgres"
+	defaultTimeout = 10 * time.Second
+)
+
+// Config is the configuration for the PostgreSQL plugin.
+type Config struct {
+	// Driver is the name of the database/sql driver (default: postgres).
+	// The driver must be registered by the program, e.g. by importing
+	// github.com/lib/pq.
+	Driver string
+
+	// DSN is the data source name, e.g.
+	// "postgres://metronome@localhost/postgres?sslmode=disable".
+	DSN string
+
+	// Timeout for gathering the stats (default: 10s).
+	Timeout time.Duration
+}
+
+// Plugin that watches a PostgreSQL server. It requires PostgreSQL 10
+// or later, which introduced pg_stat_activity.backend_type, the WAL
+// location functions and the pg_monitor role.
+//
+// Rates and the cache hit ratio are derived from the difference between
+// two snapshots, so the first snapshot reports zero rates and the cache
+// hit ratio since the last reset of the statistics.
+type Plugin struct {
+	name     string
+	db       *sql.DB
+	timeout  time.Duration
+	last     map[string]Database
+	lastTime time.Time
+}
+
+// NewPlugin initializes a new watcher for a PostgreSQL server.
+// Pass a name to differentiate between different servers. The server
+// is not contacted before the first snapshot.
+func NewPlugin(name string, config *Config) (*Plugin, error) {
+	if name == "" {
+		return nil, errors.New("no name specified")
+	}
+	if config == nil {
+		return nil, errors.New("no configuration specified")
+	}
+	if config.DSN == "" {
+		return nil, errors.New("no dsn specified")
+	}
+	driver := config.Driver
+	if driver == "" {
+		driver = defaultDriver
+	}
+	db, err := sql.Open(driver, config.DSN)
+	if err != nil {
+		return nil, err
+	}
+	// A single connection is enough to gather the stats
+	db.SetMaxOpenConns(1)
+
+	plugin := &Plugin{
+		name:    name,
+		db:      db,
+		timeout: config.Timeout,
+	}
+	if plugin.timeout <= 0 {
+		plugin.timeout = defaultTimeout
+	}
+	return plugin, nil
+}
+
+// Name of the plugin. It is prefixed with "postgres." so that instances
+// do not collide with other plugins of the same name.
+func (p *Plugin) Name() string {
+	return "postgres." + p.name
+}
+
+// Close closes the connection to the server.
+func (p *Plugin) Close() error {
+	return p.db.Close()
+}
+
+// Snapshot returns the connections, transactions, cache hit ratio,
+// replication state, locks and database sizes of the server. If the
+// server is unreachable, the health is reported as critical along with
+// the error.
+func (p *Plugin) Snapshot() (interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	states, maxConns, err := GetConnections(ctx, p.db)
+	if err != nil {
+		return unhealthy(err), nil
+	}
+	databases, err := GetDatabases(ctx, p.db)
+	if err != nil {
+		return unhealthy(err), nil
+	}
+	repl, err := GetReplication(ctx, p.db)
+	if err != nil {
+		return unhealthy(err), nil
+	}
+	locks, err := GetLocks(ctx, p.db)
+	if err != nil {
+		return unhealthy(err), nil
+	}
+	now := time.Now()
+	secs := now.Sub(p.lastTime).Seconds()
+
+	prefix := fmt.Sprintf("postgres.%s.", p.name)
+	data := map[string]interface{}{"health": plugins.HealthOK}
+	gauge := func(key string, value int64) {
+		metrics.GetOrRegisterGauge(prefix+key, nil).Update(value)
+		data[key] = value
+	}
+	gaugeFloat64 := func(key string, value float64) {
+		metrics.GetOrRegisterGaugeFloat64(prefix+key, nil).Update(value)
+		data[key] = value
+	}
+
+	// Connections
+	var conns int64
+	stateData := make(map[string]interface{})
+	for state, n := range states {
+		metrics.GetOrRegisterGauge(prefix+"connections."+state, nil).Update(n)
+		stateData[state] = n
+		conns += n
+	}
+	data["connections_by_state"] = stateData
+	gauge("connections", conns)
+	gauge("max_connections", maxConns)
+	if maxConns > 0 {
+		gaugeFloat64("connections_percent", float64(conns)/float64(maxConns)*100.0)
+	}
+
+	// Databases
+	var commits, rollbacks, blksRead, blksHit float64
+	dbData := make(map[string]interface{})
+	cur := make(map[string]Database)
+	for _, d := range databases {
+		cur[d.Name] = d
+		prev, found := p.last[d.Name]
+		c := plugins.DeltaInt(d.XactCommit, prev.XactCommit)
+		r := plugins.DeltaInt(d.XactRollback, prev.XactRollback)
+		br := plugins.DeltaInt(d.BlksRead, prev.BlksRead)
+		bh := plugins.DeltaInt(d.BlksHit, prev.BlksHit)
+		var dsecs float64
+		if found {
+			dsecs = secs
+			commits, rollbacks = commits+c, rollbacks+r
+		}
+		if found || p.last == nil {
+			// Databases created since the last snapshot are not
+			// included in the totals until the next snapshot.
+			blksRead, blksHit = blksRead+br, blksHit+bh
+		}
+		dbPrefix := prefix + "databases." + d.Name + "."
+		values := map[string]float64{
+			"commits_per_sec":   plugins.PerSec(c, dsecs),
+			"rollbacks_per_sec": plugins.PerSec(r, dsecs),
+			"cache_hit_ratio":   ratio(bh, br+bh),
+		}
+		entry := map[string]interface{}{
+			"size":      d.Size,
+			"deadlocks": d.Deadlocks,
+		}
+		for key, value := range values {
+			metrics.GetOrRegisterGaugeFloat64(dbPrefix+key, nil).Update(value)
+			entry[key] = value
+		}
+		metrics.GetOrRegisterGauge(dbPrefix+"size", nil).Update(d.Size)
+		metrics.GetOrRegisterGauge(dbPrefix+"deadlocks", nil).Update(d.Deadlocks)
+		dbData[d.Name] = entry
+	}
+	p.last = cur
+	p.lastTime = now
+	data["databases"] = dbData
+	gaugeFloat64("transactions_per_sec", plugins.PerSec(commits+rollbacks, secs))
+	gaugeFloat64("rollbacks_per_sec", plugins.PerSec(rollbacks, secs))
+	gaugeFloat64("cache_hit_ratio", ratio(blksHit, blksRead+blksHit))
+
+	// Replication
+	data["in_recovery"] = repl.InRecovery
+	if repl.InRecovery {
+		gaugeFloat64("replication_lag_seconds", repl.LagSeconds)
+	} else {
+		gauge("replicas", repl.Replicas)
+		gaugeFloat64("replication_lag_bytes", repl.LagBytes)
+	}
+
+	// Locks
+	lockData := make(map[string]interface{})
+	for mode, n := range locks.Modes {
+		metrics.GetOrRegisterGauge(prefix+"locks."+mode, nil).Update(n)
+		lockData[mode] = n
+	}
+	data["locks"] = lockData
+	gauge("locks_waiting", locks.Waiting)
+
+	// Return data
+	return data, nil
+}
+
+// unhealthy returns the snapshot of a server that cannot be watched.
+func unhealthy(err error) map[string]interface{} {
+	return map[string]interface{}{
+		"health": plugins.HealthCritical,
+		"error":  err.Error(),
+	}
+}
+
+func ratio(v, total float64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return v / total
+}
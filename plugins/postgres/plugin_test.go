@@ -0,0 +1,122 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package postgres
+
+import (
+	"database/sql/driver"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/olivere/metronome/plugins"
+	"github.com/olivere/metronome/plugins/internal/sqlstub"
+)
+
+func TestSnapshot(t *testing.T) {
+	results := primaryResults()
+	sqlstub.SetResults("snapshot", results)
+	p, err := NewPlugin("snapshot", &Config{Driver: sqlstub.Driver, DSN: "snapshot"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// The first snapshot reports zero rates and the cache hit ratio
+	// since the last reset of the statistics.
+	data, err := p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := data.(map[string]interface{})
+	tests := map[string]interface{}{
+		"health":                plugins.HealthOK,
+		"connections":           int64(10),
+		"connections_percent":   10.0,
+		"transactions_per_sec":  0.0,
+		"cache_hit_ratio":       0.95,
+		"replicas":              int64(1),
+		"replication_lag_bytes": 4096.0,
+		"locks_waiting":         int64(2),
+	}
+	for key, want := range tests {
+		if got := m[key]; got != want {
+			t.Errorf("expected %s of %v; got %v", key, want, got)
+		}
+	}
+
+	// The second snapshot reports the difference. A database created in
+	// the meantime is not included in the totals.
+	results["pg_stat_database"].Rows = [][]driver.Value{
+		{"app", int64(1500), int64(20), int64(100), int64(1100), int64(1), int64(123456)},
+		{"new", int64(100), int64(0), int64(10), int64(10), int64(0), int64(8000)},
+	}
+	p.lastTime = p.lastTime.Add(-10 * time.Second)
+	data, err = p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = data.(map[string]interface{})
+	floats := map[string]float64{
+		"transactions_per_sec": 51, // (500 commits + 10 rollbacks) / 10s
+		"rollbacks_per_sec":    1,
+		"cache_hit_ratio":      0.75, // 150 hits / (150 hits + 50 reads)
+	}
+	for key, want := range floats {
+		if got := m[key].(float64); math.Abs(got-want) > want*0.01 {
+			t.Errorf("expected %s of %v; got %v", key, want, got)
+		}
+	}
+	databases := m["databases"].(map[string]interface{})
+	newdb := databases["new"].(map[string]interface{})
+	if got := newdb["commits_per_sec"]; got != 0.0 {
+		t.Errorf("expected commits_per_sec of 0 for new database; got %v", got)
+	}
+	if got := newdb["cache_hit_ratio"]; got != 0.5 {
+		t.Errorf("expected cache_hit_ratio of 0.5 for new database; got %v", got)
+	}
+
+	// Statistics reset
+	results["pg_stat_database"].Rows = [][]driver.Value{
+		{"app", int64(5), int64(0), int64(0), int64(10), int64(0), int64(123456)},
+	}
+	data, err = p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = data.(map[string]interface{})
+	if got := m["transactions_per_sec"]; got != 0.0 {
+		t.Errorf("expected transactions_per_sec of 0 after reset; got %v", got)
+	}
+}
+
+func TestSnapshotDown(t *testing.T) {
+	sqlstub.SetResults("down", map[string]*sqlstub.Result{
+		"pg_stat_activity": {Err: errors.New("connection refused")},
+	})
+	p, err := NewPlugin("down", &Config{Driver: sqlstub.Driver, DSN: "down"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	data, err := p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := data.(map[string]interface{})
+	if got := m["health"]; got != plugins.HealthCritical {
+		t.Errorf("expected critical health; got %v", got)
+	}
+	if got := m["error"]; got != "connection refused" {
+		t.Errorf("expected error; got %v", got)
+	}
+}
+
+func TestNewPluginUnknownDriver(t *testing.T) {
+	if _, err := NewPlugin("unknown", &Config{Driver: "unknown", DSN: "x"}); err == nil {
+		t.Error("expected error for unknown driver")
+	}
+}
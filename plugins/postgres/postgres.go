@@ -0,0 +1,147 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Database contains the counters and size of a database from
+// pg_stat_database. Counters are cumulative since the last reset of
+// the statistics.
+type Database struct {
+	Name         string
+	XactCommit   int64
+	XactRollback int64
+	BlksRead     int64 // blocks read from disk
+	BlksHit      int64 // blocks found in the buffer cache
+	Deadlocks    int64
+	Size         int64 // in bytes
+}
+
+// Replication is the replication state of a server.
+type Replication struct {
+	InRecovery bool // true on a standby
+
+	// LagSeconds is the time since the last transaction was replayed,
+	// on a standby.
+	LagSeconds float64
+
+	// Replicas is the number of standbys connected to a primary, and
+	// LagBytes the largest replay lag of them in bytes of WAL.
+	Replicas int64
+	LagBytes float64
+}
+
+// Locks contains the number of locks by mode (e.g. "AccessShareLock")
+// and the number of locks waited for.
+type Locks struct {
+	Modes   map[string]int64
+	Waiting int64
+}
+
+// GetConnections returns the number of client connections by state
+// from pg_stat_activity, e.g. "active" or "idle in transaction", and
+// the max_connections setting. Background processes like the
+// checkpointer are not counted. It requires PostgreSQL 10 or later.
+func GetConnections(ctx context.Context, db *sql.DB) (states map[string]int64, max int64, err error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT COALESCE(state, 'unknown'), count(*) FROM pg_stat_activity
+		WHERE backend_type = 'client backend' GROUP BY 1`)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	states = make(map[string]int64)
+	for rows.Next() {
+		var state string
+		var n int64
+		if err := rows.Scan(&state, &n); err != nil {
+			return nil, 0, err
+		}
+		states[state] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	err = db.QueryRowContext(ctx, `SELECT setting::bigint FROM pg_settings WHERE name = 'max_connections'`).Scan(&max)
+	if err != nil {
+		return nil, 0, err
+	}
+	return states, max, nil
+}
+
+// GetDatabases returns the stats of all databases that accept
+// connections, excluding templates.
+func GetDatabases(ctx context.Context, db *sql.DB) ([]Database, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT d.datname, s.xact_commit, s.xact_rollback, s.blks_read, s.blks_hit,
+			s.deadlocks, pg_database_size(d.datname)
+		FROM pg_database d JOIN pg_stat_database s ON s.datid = d.oid
+		WHERE NOT d.datistemplate AND d.datallowconn`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var databases []Database
+	for rows.Next() {
+		var d Database
+		if err := rows.Scan(&d.Name, &d.XactCommit, &d.XactRollback, &d.BlksRead, &d.BlksHit, &d.Deadlocks, &d.Size); err != nil {
+			return nil, err
+		}
+		databases = append(databases, d)
+	}
+	return databases, rows.Err()
+}
+
+// GetReplication returns the replication state of the server. It
+// requires PostgreSQL 10 or later.
+func GetReplication(ctx context.Context, db *sql.DB) (*Replication, error) {
+	r := &Replication{}
+	err := db.QueryRowContext(ctx, `
+		SELECT pg_is_in_recovery(),
+			COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)::float8`).
+		Scan(&r.InRecovery, &r.LagSeconds)
+	if err != nil {
+		return nil, err
+	}
+	if r.InRecovery {
+		return r, nil
+	}
+	r.LagSeconds = 0
+	err = db.QueryRowContext(ctx, `
+		SELECT count(*),
+			COALESCE(max(pg_wal_lsn_diff(pg_current_wal_lsn(), replay_lsn)), 0)::float8
+		FROM pg_stat_replication`).
+		Scan(&r.Replicas, &r.LagBytes)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetLocks returns the locks held and waited for from pg_locks.
+func GetLocks(ctx context.Context, db *sql.DB) (*Locks, error) {
+	rows, err := db.QueryContext(ctx, `SELECT mode, granted, count(*) FROM pg_locks GROUP BY 1, 2`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	locks := &Locks{Modes: make(map[string]int64)}
+	for rows.Next() {
+		var mode string
+		var granted bool
+		var n int64
+		if err := rows.Scan(&mode, &granted, &n); err != nil {
+			return nil, err
+		}
+		locks.Modes[mode] += n
+		if !granted {
+			locks.Waiting += n
+		}
+	}
+	return locks, rows.Err()
+}
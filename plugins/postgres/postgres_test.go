@@ -0,0 +1,143 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+
+	"github.com/olivere/metronome/plugins/internal/sqlstub"
+)
+
+// primaryResults are the results of a primary with one standby.
+func primaryResults() map[string]*sqlstub.Result {
+	return map[string]*sqlstub.Result{
+		"backend_type = 'client backend'": {
+			Columns: []string{"state", "count"},
+			Rows:    [][]driver.Value{{"active", int64(3)}, {"idle", int64(7)}},
+		},
+		"max_connections": {
+			Columns: []string{"setting"},
+			Rows:    [][]driver.Value{{int64(100)}},
+		},
+		"pg_stat_database": {
+			Columns: []string{"datname", "xact_commit", "xact_rollback", "blks_read", "blks_hit", "deadlocks", "size"},
+			Rows: [][]driver.Value{
+				{"app", int64(1000), int64(10), int64(50), int64(950), int64(1), int64(123456)},
+			},
+		},
+		"pg_is_in_recovery": {
+			Columns: []string{"pg_is_in_recovery", "lag"},
+			Rows:    [][]driver.Value{{false, float64(0)}},
+		},
+		"pg_stat_replication": {
+			Columns: []string{"count", "lag"},
+			Rows:    [][]driver.Value{{int64(1), float64(4096)}},
+		},
+		"pg_locks": {
+			Columns: []string{"mode", "granted", "count"},
+			Rows: [][]driver.Value{
+				{"AccessShareLock", true, int64(5)},
+				{"ExclusiveLock", true, int64(1)},
+				{"ExclusiveLock", false, int64(2)},
+			},
+		},
+	}
+}
+
+func openStub(t *testing.T, dsn string, results map[string]*sqlstub.Result) *sql.DB {
+	sqlstub.SetResults(dsn, results)
+	db, err := sql.Open(sqlstub.Driver, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestGetConnections(t *testing.T) {
+	db := openStub(t, "connections", primaryResults())
+	defer db.Close()
+
+	states, max, err := GetConnections(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int64{"active": 3, "idle": 7}
+	if !reflect.DeepEqual(states, want) {
+		t.Errorf("expected %v; got %v", want, states)
+	}
+	if max != 100 {
+		t.Errorf("expected max connections of 100; got %d", max)
+	}
+}
+
+func TestGetDatabases(t *testing.T) {
+	db := openStub(t, "databases", primaryResults())
+	defer db.Close()
+
+	databases, err := GetDatabases(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Database{{
+		Name:         "app",
+		XactCommit:   1000,
+		XactRollback: 10,
+		BlksRead:     50,
+		BlksHit:      950,
+		Deadlocks:    1,
+		Size:         123456,
+	}}
+	if !reflect.DeepEqual(databases, want) {
+		t.Errorf("expected %+v; got %+v", want, databases)
+	}
+}
+
+func TestGetReplication(t *testing.T) {
+	results := primaryResults()
+	db := openStub(t, "replication", results)
+	defer db.Close()
+
+	r, err := GetReplication(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Replication{Replicas: 1, LagBytes: 4096}
+	if *r != want {
+		t.Errorf("expected %+v; got %+v", want, *r)
+	}
+
+	// On a standby, pg_stat_replication is not queried
+	results["pg_is_in_recovery"].Rows = [][]driver.Value{{true, float64(2.5)}}
+	delete(results, "pg_stat_replication")
+	r, err = GetReplication(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = Replication{InRecovery: true, LagSeconds: 2.5}
+	if *r != want {
+		t.Errorf("expected %+v; got %+v", want, *r)
+	}
+}
+
+func TestGetLocks(t *testing.T) {
+	db := openStub(t, "locks", primaryResults())
+	defer db.Close()
+
+	locks, err := GetLocks(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int64{"AccessShareLock": 5, "ExclusiveLock": 3}
+	if !reflect.DeepEqual(locks.Modes, want) {
+		t.Errorf("expected %v; got %v", want, locks.Modes)
+	}
+	if locks.Waiting != 2 {
+		t.Errorf("expected 2 locks waiting; got %d", locks.Waiting)
+	}
+}
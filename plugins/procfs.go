@@ -0,0 +1,82 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package plugins
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ProcRoot is the mount point of the proc filesystem that plugins
+	// read from. Set it e.g. to "/host/proc" to watch the host from
+	// inside a container, or to a directory with fixtures in tests.
+	ProcRoot = "/proc"
+
+	// SysRoot is the mount point of the sys filesystem that plugins
+	// read from, e.g. "/host/sys".
+	SysRoot = "/sys"
+
+	// HostRoot is where the root filesystem of the host is mounted,
+	// e.g. "/host" inside a container. Mount points and other files of
+	// the host, like /var/run/utmp, are looked up below HostRoot.
+	HostRoot = "/"
+)
+
+// ProcPath returns the path of a file below ProcRoot,
+// e.g. ProcPath("stat").
+func ProcPath(elem ...string) string {
+	return filepath.Join(append([]string{ProcRoot}, elem...)...)
+}
+
+// NamespacePath returns the path of a file below the procfs directory
+// of the process whose mount and network namespaces are reported on,
+// e.g. NamespacePath("mountinfo"). If a HostRoot is configured, i.e. when
+// watching the host from inside a container, this is init (/proc/1), and
+// metronomed itself (/proc/self) otherwise.
+func NamespacePath(elem ...string) string {
+	pid := "self"
+	if HostRoot != "" && filepath.Clean(HostRoot) != "/" {
+		pid = "1"
+	}
+	return ProcPath(append([]string{pid}, elem...)...)
+}
+
+// SysPath returns the path of a file below SysRoot,
+// e.g. SysPath("class", "net").
+func SysPath(elem ...string) string {
+	return filepath.Join(append([]string{SysRoot}, elem...)...)
+}
+
+// HostPath returns the path of a file below HostRoot,
+// e.g. HostPath("/var/run/utmp").
+func HostPath(elem ...string) string {
+	return filepath.Join(append([]string{HostRoot}, elem...)...)
+}
+
+// BootTime returns the time of boot in seconds since the epoch from
+// the btime line of /proc/stat.
+func BootTime() (int64, error) {
+	f, err := os.Open(ProcPath("stat"))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "btime" {
+			return strconv.ParseInt(fields[1], 10, 64)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, errors.New("btime not found")
+}
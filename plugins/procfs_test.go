@@ -0,0 +1,29 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package plugins
+
+import "testing"
+
+func TestNamespacePath(t *testing.T) {
+	defer func(root string) { ProcRoot = root }(ProcRoot)
+	defer func(root string) { HostRoot = root }(HostRoot)
+
+	tests := []struct {
+		ProcRoot string
+		HostRoot string
+		Want     string
+	}{
+		{"/proc", "/", "/proc/self/net/dev"},
+		{"/proc", "", "/proc/self/net/dev"},
+		{"/host/proc", "/host", "/host/proc/1/net/dev"},
+		{"/host/proc", "/host/", "/host/proc/1/net/dev"},
+	}
+	for _, test := range tests {
+		ProcRoot, HostRoot = test.ProcRoot, test.HostRoot
+		if got := NamespacePath("net", "dev"); got != test.Want {
+			t.Errorf("ProcRoot=%q, HostRoot=%q: expected %s; got %s", test.ProcRoot, test.HostRoot, test.Want, got)
+		}
+	}
+}
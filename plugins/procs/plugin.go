@@ -0,0 +1,256 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package procs
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+// Config is the configuration for the procs plugin.
+type Config struct {
+	// Watch is the set of processes to report individually, by name.
+	Watch map[string]*WatchConfig
+}
+
+// WatchConfig selects the processes to watch. Exactly one of Name,
+// Cmdline and Pidfile must be set.
+type WatchConfig struct {
+	// Name is the executable name of the processes, e.g. "java", as in
+	// /proc/<pid>/comm. The kernel truncates it to 15 characters, so
+	// only the first 15 characters of Name are compared. Use Cmdline to
+	// tell apart processes with longer names.
+	Name string
+	// Cmdline is a regular expression matched against the command line.
+	Cmdline string
+	// Pidfile is a file containing the ID of the process. It is looked
+	// up below the host root, like the processes in /proc.
+	Pidfile string
+}
+
+// watch is a set of watched processes.
+type watch struct {
+	name    string
+	exe     string
+	cmdline *regexp.Regexp
+	pidfile string
+
+	lastTicks map[int]uint64 // CPU ticks by process ID
+}
+
+// Plugin watches the processes of a machine.
+//
+// CPU usage of watched processes is derived from the difference between
+// two snapshots, so the first snapshot reports zero CPU usage.
+type Plugin struct {
+	watches  []*watch
+	lastTime time.Time
+
+	total   metrics.Gauge
+	threads metrics.Gauge
+}
+
+// NewPlugin initializes a new Plugin to watch the processes.
+func NewPlugin(config *Config) (*Plugin, error) {
+	if config == nil {
+		return nil, errors.New("no configuration specified")
+	}
+	p := &Plugin{}
+	for name, wc := range config.Watch {
+		w := &watch{
+			name:      name,
+			exe:       wc.Name,
+			pidfile:   wc.Pidfile,
+			lastTicks: make(map[int]uint64),
+		}
+		if len(w.exe) > MaxNameLen {
+			w.exe = w.exe[:MaxNameLen]
+		}
+		n := 0
+		if wc.Name != "" {
+			n++
+		}
+		if wc.Cmdline != "" {
+			re, err := regexp.Compile(wc.Cmdline)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cmdline for %q: %v", name, err)
+			}
+			w.cmdline = re
+			n++
+		}
+		if wc.Pidfile != "" {
+			n++
+		}
+		if n != 1 {
+			return nil, fmt.Errorf("specify one of name, cmdline or pidfile for %q", name)
+		}
+		p.watches = append(p.watches, w)
+	}
+
+	p.total = metrics.NewGauge()
+	metrics.Register("procs.total", p.total)
+	p.threads = metrics.NewGauge()
+	metrics.Register("procs.threads", p.threads)
+	return p, nil
+}
+
+// Name of the plugin.
+func (p *Plugin) Name() string {
+	return "procs"
+}
+
+// Snapshot returns the number of processes by state and the resource
+// usage of the watched processes.
+func (p *Plugin) Snapshot() (interface{}, error) {
+	pids, err := ListPIDs()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	secs := now.Sub(p.lastTime).Seconds()
+	p.lastTime = now
+
+	// Resolve pidfiles once per snapshot
+	pidfiles := make(map[*watch]int)
+	for _, w := range p.watches {
+		if w.pidfile != "" {
+			if pid, err := readPidfile(w.pidfile); err == nil {
+				pidfiles[w] = pid
+			}
+		}
+	}
+
+	states := make(map[string]int64)
+	var threads int64
+	matches := make(map[*watch][]*Process)
+	for _, pid := range pids {
+		proc, err := GetProcess(pid)
+		if err != nil {
+			// Process has exited in the meantime
+			continue
+		}
+		states[StateName(proc.State)]++
+		threads += proc.NumThreads
+		var cmdline string
+		var cmdlineRead bool
+		for _, w := range p.watches {
+			if w.cmdline != nil && !cmdlineRead {
+				// Read the command line at most once per process
+				cmdline, _ = GetCmdline(proc.PID)
+				cmdlineRead = true
+			}
+			if p.match(w, proc, pidfiles[w], cmdline) {
+				matches[w] = append(matches[w], proc)
+			}
+		}
+	}
+
+	// Uptime is unknown (-1) if the boot time cannot be read
+	secsSinceBoot := int64(-1)
+	if bootTime, err := plugins.BootTime(); err == nil {
+		secsSinceBoot = now.Unix() - bootTime
+	}
+	watched := make(map[string]interface{})
+	for _, w := range p.watches {
+		watched[w.name] = p.usage(w, matches[w], secs, secsSinceBoot)
+	}
+
+	// Update metrics
+	p.total.Update(int64(len(pids)))
+	p.threads.Update(threads)
+	stateData := make(map[string]interface{})
+	for state, n := range states {
+		metrics.GetOrRegisterGauge("procs.states."+state, nil).Update(n)
+		stateData[state] = n
+	}
+
+	// Return data
+	return map[string]interface{}{
+		"total":   p.total.Value(),
+		"threads": p.threads.Value(),
+		"states":  stateData,
+		"watched": watched,
+	}, nil
+}
+
+// match returns true if proc is watched by w. pid is the process ID
+// read from the pidfile of w, if any, and cmdline is the command line
+// of proc if w matches by command line.
+func (p *Plugin) match(w *watch, proc *Process, pid int, cmdline string) bool {
+	switch {
+	case w.exe != "":
+		return proc.Name == w.exe
+	case w.cmdline != nil:
+		return cmdline != "" && w.cmdline.MatchString(cmdline)
+	case w.pidfile != "":
+		return pid > 0 && proc.PID == pid
+	}
+	return false
+}
+
+// usage sums up the resource usage of the processes watched by w.
+// Uptime is that of the oldest process; it is skipped if secsSinceBoot
+// is negative, i.e. the boot time is unknown.
+func (p *Plugin) usage(w *watch, procs []*Process, secs float64, secsSinceBoot int64) map[string]interface{} {
+	var cpuPercent float64
+	var rss, threads, fds, uptime int64
+	ticks := make(map[int]uint64)
+	for _, proc := range procs {
+		t := proc.UTime + proc.STime
+		ticks[proc.PID] = t
+		if prev, found := w.lastTicks[proc.PID]; found {
+			cpuPercent += plugins.Rate(t, prev, secs) / ClockTicks * 100.0
+		}
+		rss += proc.RSS
+		threads += proc.NumThreads
+		if n, err := NumFDs(proc.PID); err == nil {
+			fds += int64(n)
+		}
+		if secsSinceBoot >= 0 {
+			if up := secsSinceBoot - int64(proc.StartTime/ClockTicks); up > uptime {
+				uptime = up
+			}
+		}
+	}
+	w.lastTicks = ticks
+
+	prefix := "procs.watched." + w.name + "."
+	metrics.GetOrRegisterGauge(prefix+"count", nil).Update(int64(len(procs)))
+	metrics.GetOrRegisterGaugeFloat64(prefix+"cpu_percent", nil).Update(cpuPercent)
+	metrics.GetOrRegisterGauge(prefix+"rss", nil).Update(rss)
+	metrics.GetOrRegisterGauge(prefix+"open_fds", nil).Update(fds)
+	metrics.GetOrRegisterGauge(prefix+"threads", nil).Update(threads)
+
+	data := map[string]interface{}{
+		"count":       len(procs),
+		"cpu_percent": cpuPercent,
+		"rss":         rss,
+		"open_fds":    fds,
+		"threads":     threads,
+	}
+	if secsSinceBoot >= 0 {
+		metrics.GetOrRegisterGauge(prefix+"uptime", nil).Update(uptime)
+		data["uptime"] = uptime
+	}
+	return data
+}
+
+// readPidfile reads a process ID from a file below the host root.
+func readPidfile(filename string) (int, error) {
+	data, err := ioutil.ReadFile(plugins.HostPath(filename))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
@@ -0,0 +1,47 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package procs
+
+// ClockTicks is the number of clock ticks per second that the kernel
+// uses for CPU times (USER_HZ), which is 100 on all common platforms.
+const ClockTicks = 100
+
+// MaxNameLen is the length that the kernel truncates the executable
+// names of processes to (TASK_COMM_LEN - 1).
+const MaxNameLen = 15
+
+// Process is a snapshot of a process.
+type Process struct {
+	PID        int
+	PPID       int
+	Name       string // executable name, truncated to MaxNameLen characters
+	State      byte   // e.g. 'R' for running, see proc(5)
+	UTime      uint64 // clock ticks in user mode
+	STime      uint64 // clock ticks in kernel mode
+	NumThreads int64
+	StartTime  uint64 // clock ticks after boot
+	RSS        int64  // resident set size in bytes
+}
+
+// StateName returns a human readable name for a process state.
+func StateName(state byte) string {
+	switch state {
+	case 'R':
+		return "running"
+	case 'S':
+		return "sleeping"
+	case 'D':
+		return "disk_sleep"
+	case 'Z':
+		return "zombie"
+	case 'T', 't':
+		return "stopped"
+	case 'I':
+		return "idle"
+	case 'X', 'x':
+		return "dead"
+	}
+	return "other"
+}
@@ -0,0 +1,31 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+// +build darwin
+
+package procs
+
+import "errors"
+
+var errNotSupported = errors.New("procs: not supported on darwin")
+
+// ListPIDs is not supported on Darwin.
+func ListPIDs() ([]int, error) {
+	return nil, errNotSupported
+}
+
+// GetProcess is not supported on Darwin.
+func GetProcess(pid int) (*Process, error) {
+	return nil, errNotSupported
+}
+
+// GetCmdline is not supported on Darwin.
+func GetCmdline(pid int) (string, error) {
+	return "", errNotSupported
+}
+
+// NumFDs is not supported on Darwin.
+func NumFDs(pid int) (int, error) {
+	return 0, errNotSupported
+}
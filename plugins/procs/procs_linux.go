@@ -0,0 +1,98 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+// +build linux
+
+package procs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+// ListPIDs returns the IDs of all processes.
+func ListPIDs() ([]int, error) {
+	entries, err := ioutil.ReadDir(plugins.ProcRoot)
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, entry := range entries {
+		if pid, err := strconv.Atoi(entry.Name()); err == nil && entry.IsDir() {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
+
+// GetProcess returns a snapshot of the process with the given ID from
+// /proc/<pid>/stat.
+func GetProcess(pid int) (*Process, error) {
+	data, err := ioutil.ReadFile(plugins.ProcPath(strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return nil, err
+	}
+	return ParseProcStat(data, os.Getpagesize())
+}
+
+// ParseProcStat parses the contents of /proc/<pid>/stat.
+func ParseProcStat(data []byte, pageSize int) (*Process, error) {
+	// The name is in parentheses and may contain spaces and parentheses,
+	// so split at the first "(" and the last ")".
+	start := bytes.IndexByte(data, '(')
+	end := bytes.LastIndexByte(data, ')')
+	if start < 0 || end < start {
+		return nil, errors.New("invalid stat format")
+	}
+	pid, err := strconv.Atoi(string(bytes.TrimSpace(data[:start])))
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(string(data[end+1:]))
+	if len(fields) < 22 {
+		return nil, fmt.Errorf("invalid stat format: %d fields", len(fields))
+	}
+	p := &Process{
+		PID:   pid,
+		Name:  string(data[start+1 : end]),
+		State: fields[0][0],
+	}
+	// fields[i] is field i+3 in proc(5)
+	p.PPID, _ = strconv.Atoi(fields[1])
+	p.UTime, _ = strconv.ParseUint(fields[11], 10, 64)
+	p.STime, _ = strconv.ParseUint(fields[12], 10, 64)
+	p.NumThreads, _ = strconv.ParseInt(fields[17], 10, 64)
+	p.StartTime, _ = strconv.ParseUint(fields[19], 10, 64)
+	rss, _ := strconv.ParseInt(fields[21], 10, 64)
+	p.RSS = rss * int64(pageSize)
+	return p, nil
+}
+
+// GetCmdline returns the command line of a process, with arguments
+// separated by spaces.
+func GetCmdline(pid int) (string, error) {
+	data, err := ioutil.ReadFile(plugins.ProcPath(strconv.Itoa(pid), "cmdline"))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(bytes.Replace(data, []byte{0}, []byte{' '}, -1))), nil
+}
+
+// NumFDs returns the number of open file descriptors of a process.
+func NumFDs(pid int) (int, error) {
+	f, err := os.Open(plugins.ProcPath(strconv.Itoa(pid), "fd"))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	names, err := f.Readdirnames(-1)
+	return len(names), err
+}
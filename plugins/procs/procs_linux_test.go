@@ -0,0 +1,113 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+//go:build linux
+// +build linux
+
+package procs
+
+import (
+	"os"
+	"testing"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+func TestParseProcStat(t *testing.T) {
+	data := []byte("1234 (my (weird) proc) S 1 1234 1234 0 -1 4194560 100 0 0 0 250 50 0 0 20 0 4 0 5000 123456789 2048 18446744073709551615\n")
+	p, err := ParseProcStat(data, 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Process{
+		PID:        1234,
+		PPID:       1,
+		Name:       "my (weird) proc",
+		State:      'S',
+		UTime:      250,
+		STime:      50,
+		NumThreads: 4,
+		StartTime:  5000,
+		RSS:        2048 * 4096,
+	}
+	if *p != want {
+		t.Errorf("expected %+v; got %+v", want, *p)
+	}
+}
+
+func TestParseProcStatInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"1234 no name S 1\n",
+		"x (bash) S 1 2 3 4 5 6 7 8 9 10 11 12 13 14 15 16 17 18 19 20 21\n",
+		"1234 (bash) S 1 2 3\n",
+	}
+	for _, input := range tests {
+		if _, err := ParseProcStat([]byte(input), 4096); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	defer func(root string) { plugins.ProcRoot = root }(plugins.ProcRoot)
+	defer func(root string) { plugins.HostRoot = root }(plugins.HostRoot)
+	plugins.ProcRoot = "testdata/proc"
+	plugins.HostRoot = "testdata/host"
+
+	p, err := NewPlugin(&Config{
+		Watch: map[string]*WatchConfig{
+			"app":  {Pidfile: "/var/run/app.pid"},
+			"es":   {Name: "elasticsearch-server"}, // longer than comm
+			"cmd":  {Cmdline: `--verbose$`},
+			"none": {Name: "nginx"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := data.(map[string]interface{})
+	if got := m["total"]; got != int64(2) {
+		t.Errorf("expected 2 processes; got %v", got)
+	}
+	if got := m["threads"]; got != int64(64) {
+		t.Errorf("expected 64 threads; got %v", got)
+	}
+	states := m["states"].(map[string]interface{})
+	if states["sleeping"] != int64(1) || states["running"] != int64(1) {
+		t.Errorf("expected 1 sleeping and 1 running process; got %v", states)
+	}
+
+	watched := m["watched"].(map[string]interface{})
+	tests := []struct {
+		Name  string
+		Count int
+		RSS   int64
+		FDs   int64
+	}{
+		{"app", 1, 2048 * int64(os.Getpagesize()), 3},
+		{"cmd", 1, 2048 * int64(os.Getpagesize()), 3},
+		{"es", 1, 4096 * int64(os.Getpagesize()), 1},
+		{"none", 0, 0, 0},
+	}
+	for _, test := range tests {
+		w := watched[test.Name].(map[string]interface{})
+		if got := w["count"]; got != test.Count {
+			t.Errorf("%s: expected count of %d; got %v", test.Name, test.Count, got)
+		}
+		if got := w["rss"]; got != test.RSS {
+			t.Errorf("%s: expected rss of %d; got %v", test.Name, test.RSS, got)
+		}
+		if got := w["open_fds"]; got != test.FDs {
+			t.Errorf("%s: expected %d open fds; got %v", test.Name, test.FDs, got)
+		}
+		if _, found := w["uptime"]; !found {
+			t.Errorf("%s: expected uptime", test.Name)
+		}
+	}
+}
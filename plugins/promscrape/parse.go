@@ -0,0 +1,167 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package promscrape
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Sample is a single sample of a series.
+type Sample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+}
+
+// Key returns the identity of the series of the sample in the text
+// format, e.g. `http_requests_total{code="200",method="get"}`, with
+// the labels sorted by name.
+func (s Sample) Key() string {
+	if len(s.Labels) == 0 {
+		return s.Name
+	}
+	names := make([]string, 0, len(s.Labels))
+	for name := range s.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b bytes.Buffer
+	b.WriteString(s.Name)
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString("=")
+		b.WriteString(strconv.Quote(s.Labels[name]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// ParseText parses the Prometheus text exposition format, which is also
+// accepted for OpenMetrics. Comments, HELP and TYPE lines are skipped,
+// as are timestamps.
+func ParseText(r io.Reader) ([]Sample, error) {
+	var samples []Sample
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineno := 0
+	for scanner.Scan() {
+		lineno++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		s, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineno, err)
+		}
+		samples = append(samples, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+// parseLine parses a line like `name{label="value",...} value [timestamp]`.
+func parseLine(line string) (Sample, error) {
+	s := Sample{Labels: make(map[string]string)}
+
+	i := 0
+	for i < len(line) && isNameChar(line[i], i == 0) {
+		i++
+	}
+	if i == 0 {
+		return s, fmt.Errorf("invalid metric name in %q", line)
+	}
+	s.Name = line[:i]
+	rest := line[i:]
+
+	if strings.HasPrefix(rest, "{") {
+		n, err := parseLabels(rest, s.Labels)
+		if err != nil {
+			return s, err
+		}
+		rest = rest[n:]
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) < 1 || len(fields) > 2 {
+		return s, fmt.Errorf("invalid sample %q", line)
+	}
+	v, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return s, fmt.Errorf("invalid value %q", fields[0])
+	}
+	s.Value = v
+	return s, nil
+}
+
+// parseLabels parses the label set at the start of s into labels and
+// returns the number of bytes consumed, including the braces.
+func parseLabels(s string, labels map[string]string) (int, error) {
+	i := 1 // skip {
+	for {
+		for i < len(s) && (s[i] == ' ' || s[i] == ',') {
+			i++
+		}
+		if i >= len(s) {
+			return 0, fmt.Errorf("unterminated labels in %q", s)
+		}
+		if s[i] == '}' {
+			return i + 1, nil
+		}
+
+		start := i
+		for i < len(s) && isNameChar(s[i], i == start) && s[i] != ':' {
+			i++
+		}
+		name := s[start:i]
+		if name == "" || i+1 >= len(s) || s[i] != '=' || s[i+1] != '"' {
+			return 0, fmt.Errorf("invalid label in %q", s)
+		}
+		i += 2 // skip ="
+
+		var value bytes.Buffer
+		for {
+			if i >= len(s) {
+				return 0, fmt.Errorf("unterminated label value in %q", s)
+			}
+			c := s[i]
+			i++
+			if c == '"' {
+				break
+			}
+			if c == '\\' && i < len(s) {
+				c = s[i]
+				i++
+				if c == 'n' {
+					c = '\n'
+				}
+			}
+			value.WriteByte(c)
+		}
+		labels[name] = value.String()
+	}
+}
+
+func isNameChar(c byte, first bool) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_', c == ':':
+		return true
+	case c >= '0' && c <= '9':
+		return !first
+	}
+	return false
+}
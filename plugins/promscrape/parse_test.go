@@ -0,0 +1,154 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package promscrape
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		Line   string
+		Name   string
+		Labels map[string]string
+		Value  float64
+	}{
+		{
+			Line:   `up 1`,
+			Name:   "up",
+			Labels: map[string]string{},
+			Value:  1,
+		},
+		{
+			Line:   `http_requests_total{method="post",code="200"} 1027`,
+			Name:   "http_requests_total",
+			Labels: map[string]string{"method": "post", "code": "200"},
+			Value:  1027,
+		},
+		{
+			// Timestamps are skipped
+			Line:   `http_requests_total{method="post",code="400"} 3 1395066363000`,
+			Name:   "http_requests_total",
+			Labels: map[string]string{"method": "post", "code": "400"},
+			Value:  3,
+		},
+		{
+			// Escaped backslash, double quote and line feed
+			Line:   `msdos_file_access_time_seconds{path="C:\\DIR\\FILE.TXT",error="Cannot find file:\n\"FILE.TXT\""} 1.458255915e9`,
+			Name:   "msdos_file_access_time_seconds",
+			Labels: map[string]string{"path": `C:\DIR\FILE.TXT`, "error": "Cannot find file:\n\"FILE.TXT\""},
+			Value:  1.458255915e9,
+		},
+		{
+			// Trailing comma and spaces within the label set
+			Line:   `node_cpu_seconds_total{ cpu="0", mode="idle", } 1234.5`,
+			Name:   "node_cpu_seconds_total",
+			Labels: map[string]string{"cpu": "0", "mode": "idle"},
+			Value:  1234.5,
+		},
+		{
+			Line:   `rpc_duration_seconds{quantile="0.5"} +Inf`,
+			Name:   "rpc_duration_seconds",
+			Labels: map[string]string{"quantile": "0.5"},
+			Value:  math.Inf(1),
+		},
+		{
+			Line:   `temperature_celsius -Inf`,
+			Name:   "temperature_celsius",
+			Labels: map[string]string{},
+			Value:  math.Inf(-1),
+		},
+		{
+			Line:   `namespace:metric_name:rate5m{le="+Inf"} -3.5e-2`,
+			Name:   "namespace:metric_name:rate5m",
+			Labels: map[string]string{"le": "+Inf"},
+			Value:  -0.035,
+		},
+	}
+	for i, test := range tests {
+		s, err := parseLine(test.Line)
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		if s.Name != test.Name {
+			t.Errorf("#%d: expected name %q; got %q", i, test.Name, s.Name)
+		}
+		if !reflect.DeepEqual(s.Labels, test.Labels) {
+			t.Errorf("#%d: expected labels %v; got %v", i, test.Labels, s.Labels)
+		}
+		if s.Value != test.Value {
+			t.Errorf("#%d: expected value %v; got %v", i, test.Value, s.Value)
+		}
+	}
+}
+
+func TestParseLineNaN(t *testing.T) {
+	s, err := parseLine(`go_gc_duration_seconds{quantile="1"} NaN`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !math.IsNaN(s.Value) {
+		t.Errorf("expected NaN; got %v", s.Value)
+	}
+}
+
+func TestParseLineInvalid(t *testing.T) {
+	tests := []string{
+		`1up 1`,
+		`{code="200"} 1`,
+		`up`,
+		`up one`,
+		`up 1 2 3`,
+		`http_requests_total{code="200" 1`,
+		`http_requests_total{code="200} 1`,
+		`http_requests_total{code=200} 1`,
+		`http_requests_total{="200"} 1`,
+	}
+	for _, line := range tests {
+		if _, err := parseLine(line); err == nil {
+			t.Errorf("expected error for %q", line)
+		}
+	}
+}
+
+func TestParseText(t *testing.T) {
+	text := `# HELP http_requests_total The total number of HTTP requests.
+# TYPE http_requests_total counter
+http_requests_total{method="post",code="200"} 1027 1395066363000
+
+# A comment
+up 1
+`
+	samples, err := ParseText(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 2 {
+		t.Fatalf("expected 2 samples; got %d", len(samples))
+	}
+	if got, want := samples[0].Key(), `http_requests_total{code="200",method="post"}`; got != want {
+		t.Errorf("expected key %s; got %s", want, got)
+	}
+	if got, want := samples[1].Key(), "up"; got != want {
+		t.Errorf("expected key %s; got %s", want, got)
+	}
+
+	_, err = ParseText(strings.NewReader("up 1\nup x\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Errorf("expected error in line 2; got %v", err)
+	}
+}
+
+func TestSampleKeyEscapes(t *testing.T) {
+	s := Sample{Name: "x", Labels: map[string]string{"path": `C:\DIR`, "msg": "a\n\"b\""}}
+	want := `x{msg="a\n\"b\"",path="C:\\DIR"}`
+	if got := s.Key(); got != want {
+		t.Errorf("expected %s; got %s", want, got)
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package promscrape
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+const (
+	defaultTimeout = 10 * time.Second
+)
+
+// Config is the configuration for the promscrape plugin.
+type Config struct {
+	// Targets are the URLs to scrape, e.g. "http://localhost:9100/metrics".
+	Targets []string
+
+	// Timeout for scraping a target (default: 10s). Targets are
+	// scraped concurrently, so this also bounds a snapshot.
+	Timeout time.Duration
+
+	// Include and Exclude are patterns of metric names to report, as
+	// used by path.Match, e.g. "node_cpu_*". They are applied before
+	// relabeling.
+	Include []string
+	Exclude []string
+
+	// Relabel rules are applied to each sample in order, after the
+	// "instance" label has been set to the host of the target.
+	Relabel []RelabelRule
+}
+
+// Plugin scrapes targets that expose metrics in the Prometheus text
+// format and reports the selected series.
+type Plugin struct {
+	name    string
+	targets []string
+	client  *http.Client
+	filter  *plugins.Filter
+	rules   []*relabeler
+
+	// series are the keys of the gauges registered by the last
+	// snapshot, so that series that disappear can be unregistered.
+	series map[string]bool
+}
+
+// NewPlugin initializes a new Plugin to scrape targets.
+// Pass a name to differentiate between different sets of targets.
+func NewPlugin(name string, config *Config) (*Plugin, error) {
+	if name == "" {
+		return nil, errors.New("no name specified")
+	}
+	if config == nil {
+		return nil, errors.New("no configuration specified")
+	}
+	if len(config.Targets) == 0 {
+		return nil, errors.New("no targets specified")
+	}
+	for _, target := range config.Targets {
+		if _, err := url.Parse(target); err != nil {
+			return nil, err
+		}
+	}
+	filter, err := plugins.NewFilter(config.Include, config.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := compileRules(config.Relabel)
+	if err != nil {
+		return nil, err
+	}
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Plugin{
+		name:    name,
+		targets: config.Targets,
+		client:  &http.Client{Timeout: timeout},
+		filter:  filter,
+		rules:   rules,
+	}, nil
+}
+
+// Name of the plugin. It is prefixed with "promscrape." so that instances
+// do not collide with other plugins of the same name.
+func (p *Plugin) Name() string {
+	return "promscrape." + p.name
+}
+
+// Snapshot scrapes all targets concurrently and returns the selected
+// series, keyed by their name and labels, and the state of each target.
+// The health is warning if some targets cannot be scraped, and critical
+// if none can be. Series that were not scraped this time, e.g. because
+// their target is down, are removed from the registry.
+//
+// Samples with values that cannot be represented in JSON, i.e. NaN and
+// infinity, are skipped.
+func (p *Plugin) Snapshot() (interface{}, error) {
+	type result struct {
+		samples []Sample
+		err     error
+	}
+	results := make([]result, len(p.targets))
+	var wg sync.WaitGroup
+	for i, target := range p.targets {
+		wg.Add(1)
+		go func(i int, target string) {
+			defer wg.Done()
+			results[i].samples, results[i].err = p.scrape(target)
+		}(i, target)
+	}
+	wg.Wait()
+
+	prefix := fmt.Sprintf("promscrape.%s.", p.name)
+	series := make(map[string]interface{})
+	registered := make(map[string]bool)
+	targets := make(map[string]interface{})
+	failed := 0
+	for i, target := range p.targets {
+		if err := results[i].err; err != nil {
+			failed++
+			targets[target] = map[string]interface{}{
+				"up":    false,
+				"error": err.Error(),
+			}
+			continue
+		}
+		n := 0
+		for _, s := range results[i].samples {
+			if math.IsNaN(s.Value) || math.IsInf(s.Value, 0) {
+				continue
+			}
+			key := s.Key()
+			metrics.GetOrRegisterGaugeFloat64(prefix+key, nil).Update(s.Value)
+			registered[key] = true
+			series[key] = s.Value
+			n++
+		}
+		targets[target] = map[string]interface{}{
+			"up":      true,
+			"samples": n,
+		}
+	}
+
+	// Unregister the series missing from this scrape, so that they are
+	// not reported with stale values
+	for key := range p.series {
+		if !registered[key] {
+			metrics.Unregister(prefix + key)
+		}
+	}
+	p.series = registered
+
+	health := plugins.HealthOK
+	switch {
+	case failed == len(p.targets):
+		health = plugins.HealthCritical
+	case failed > 0:
+		health = plugins.HealthWarning
+	}
+
+	// Return data
+	return map[string]interface{}{
+		"health":  health,
+		"series":  series,
+		"targets": targets,
+	}, nil
+}
+
+// scrape fetches a target and returns its samples after filtering and
+// relabeling.
+func (p *Plugin) scrape(target string) ([]Sample, error) {
+	req, err := http.NewRequest("GET", target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/plain;version=0.0.4")
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", res.Status)
+	}
+	samples, err := ParseText(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	instance := target
+	if u, err := url.Parse(target); err == nil && u.Host != "" {
+		instance = u.Host
+	}
+	var selected []Sample
+	for _, s := range samples {
+		if !p.filter.Match(s.Name) {
+			continue
+		}
+		if _, found := s.Labels["instance"]; !found {
+			s.Labels["instance"] = instance
+		}
+		if !relabel(&s, p.rules) {
+			continue
+		}
+		selected = append(selected, s)
+	}
+	return selected, nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package promscrape
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+func TestSnapshot(t *testing.T) {
+	text := "up 1\nhttp_requests_total{code=\"200\"} 10\nhttp_requests_total{code=\"500\"} 2\ngc_seconds NaN\n"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, text)
+	}))
+	defer ts.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	p, err := NewPlugin("snapshot", &Config{
+		Targets: []string{ts.URL, down.URL},
+		Include: []string{"http_*", "gc_*"},
+		Relabel: []RelabelRule{{Action: ActionLabelDrop, Regex: "instance"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := data.(map[string]interface{})
+	if got := m["health"]; got != plugins.HealthWarning {
+		t.Errorf("expected warning health; got %v", got)
+	}
+	series := m["series"].(map[string]interface{})
+	want := map[string]interface{}{
+		`http_requests_total{code="200"}`: 10.0,
+		`http_requests_total{code="500"}`: 2.0,
+	}
+	if len(series) != len(want) {
+		t.Errorf("expected %v; got %v", want, series)
+	}
+	for key, value := range want {
+		if got := series[key]; got != value {
+			t.Errorf("expected %s of %v; got %v", key, value, got)
+		}
+		if metrics.Get("promscrape.snapshot."+key) == nil {
+			t.Errorf("expected %s to be registered", key)
+		}
+	}
+	for _, name := range []string{"up", "gc_seconds"} {
+		if metrics.Get("promscrape.snapshot."+name) != nil {
+			t.Errorf("expected %s not to be registered", name)
+		}
+	}
+	targets := m["targets"].(map[string]interface{})
+	if up := targets[down.URL].(map[string]interface{})["up"]; up != false {
+		t.Errorf("expected %s to be down; got %v", down.URL, up)
+	}
+
+	// Series that disappear are unregistered
+	text = "http_requests_total{code=\"200\"} 11\n"
+	if _, err := p.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+	if metrics.Get(`promscrape.snapshot.http_requests_total{code="500"}`) != nil {
+		t.Error("expected series missing from the last scrape to be unregistered")
+	}
+	g, ok := metrics.Get(`promscrape.snapshot.http_requests_total{code="200"}`).(metrics.GaugeFloat64)
+	if !ok || g.Value() != 11 {
+		t.Errorf("expected gauge of 11; got %v", g)
+	}
+}
+
+func TestSnapshotConcurrent(t *testing.T) {
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		fmt.Fprint(w, "up 1\n")
+	})
+	var targets []string
+	for i := 0; i < 5; i++ {
+		ts := httptest.NewServer(slow)
+		defer ts.Close()
+		targets = append(targets, ts.URL)
+	}
+	p, err := NewPlugin("concurrent", &Config{Targets: targets})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	data, err := p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 800*time.Millisecond {
+		t.Errorf("expected targets to be scraped concurrently; took %v", d)
+	}
+	m := data.(map[string]interface{})
+	if got := m["health"]; got != plugins.HealthOK {
+		t.Errorf("expected ok health; got %v", got)
+	}
+	if got := len(m["series"].(map[string]interface{})); got != len(targets) {
+		t.Errorf("expected %d series; got %d", len(targets), got)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package promscrape
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Relabel actions, following metric_relabel_configs of Prometheus.
+const (
+	ActionReplace   = "replace"
+	ActionKeep      = "keep"
+	ActionDrop      = "drop"
+	ActionLabelDrop = "labeldrop"
+	ActionLabelKeep = "labelkeep"
+)
+
+// nameLabel is the pseudo label with the metric name.
+const nameLabel = "__name__"
+
+// RelabelRule rewrites or filters samples, like a rule of
+// metric_relabel_configs in Prometheus.
+type RelabelRule struct {
+	// SourceLabels are concatenated with Separator and matched
+	// against Regex. Use "__name__" for the metric name.
+	SourceLabels []string
+	Separator    string // default: ";"
+
+	// Regex is matched against the concatenated source labels for
+	// replace, keep and drop, and against label names for labeldrop and
+	// labelkeep. It is anchored at both ends (default: "(.*)").
+	Regex string
+
+	// TargetLabel is set to Replacement for replace, where Replacement
+	// may refer to groups of Regex, e.g. "$1" (the default).
+	TargetLabel string
+	Replacement string
+
+	// Action is one of replace (the default), keep, drop, labeldrop or
+	// labelkeep.
+	Action string
+}
+
+// relabeler is a compiled RelabelRule.
+type relabeler struct {
+	RelabelRule
+	re *regexp.Regexp
+}
+
+// compileRules validates and compiles relabel rules.
+func compileRules(rules []RelabelRule) ([]*relabeler, error) {
+	var compiled []*relabeler
+	for _, rule := range rules {
+		if rule.Separator == "" {
+			rule.Separator = ";"
+		}
+		if rule.Regex == "" {
+			rule.Regex = "(.*)"
+		}
+		if rule.Replacement == "" {
+			rule.Replacement = "$1"
+		}
+		if rule.Action == "" {
+			rule.Action = ActionReplace
+		}
+		switch rule.Action {
+		case ActionReplace:
+			if rule.TargetLabel == "" {
+				return nil, fmt.Errorf("relabel rule with action %s needs a target label", rule.Action)
+			}
+		case ActionKeep, ActionDrop, ActionLabelDrop, ActionLabelKeep:
+		default:
+			return nil, fmt.Errorf("invalid relabel action %q", rule.Action)
+		}
+		re, err := regexp.Compile("^(?:" + rule.Regex + ")$")
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, &relabeler{RelabelRule: rule, re: re})
+	}
+	return compiled, nil
+}
+
+// relabel applies the rules to a sample in order. It returns false if
+// the sample is dropped.
+func relabel(s *Sample, rules []*relabeler) bool {
+	for _, rule := range rules {
+		switch rule.Action {
+		case ActionLabelDrop, ActionLabelKeep:
+			for name := range s.Labels {
+				if rule.re.MatchString(name) == (rule.Action == ActionLabelDrop) {
+					delete(s.Labels, name)
+				}
+			}
+			continue
+		}
+
+		values := make([]string, len(rule.SourceLabels))
+		for i, name := range rule.SourceLabels {
+			if name == nameLabel {
+				values[i] = s.Name
+			} else {
+				values[i] = s.Labels[name]
+			}
+		}
+		value := strings.Join(values, rule.Separator)
+		match := rule.re.FindStringSubmatchIndex(value)
+
+		switch rule.Action {
+		case ActionKeep:
+			if match == nil {
+				return false
+			}
+		case ActionDrop:
+			if match != nil {
+				return false
+			}
+		case ActionReplace:
+			if match == nil {
+				continue
+			}
+			target := string(rule.re.ExpandString(nil, rule.TargetLabel, value, match))
+			replacement := string(rule.re.ExpandString(nil, rule.Replacement, value, match))
+			switch {
+			case target == nameLabel:
+				if replacement != "" {
+					s.Name = replacement
+				}
+			case replacement == "":
+				delete(s.Labels, target)
+			default:
+				s.Labels[target] = replacement
+			}
+		}
+	}
+	return true
+}
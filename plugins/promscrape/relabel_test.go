@@ -0,0 +1,185 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package promscrape
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRelabel(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Rules []RelabelRule
+		Keep  bool
+		Want  string // key of the sample after relabeling
+	}{
+		{
+			Name: "no rules",
+			Keep: true,
+			Want: `http_requests_total{code="200",instance="web1:9100",method="get"}`,
+		},
+		{
+			Name: "replace with groups",
+			Rules: []RelabelRule{{
+				SourceLabels: []string{"instance"},
+				Regex:        "([^:]+):.*",
+				TargetLabel:  "host",
+			}},
+			Keep: true,
+			Want: `http_requests_total{code="200",host="web1",instance="web1:9100",method="get"}`,
+		},
+		{
+			Name: "replace with separator",
+			Rules: []RelabelRule{{
+				SourceLabels: []string{"method", "code"},
+				Separator:    "/",
+				TargetLabel:  "route",
+				Replacement:  "$1",
+			}},
+			Keep: true,
+			Want: `http_requests_total{code="200",instance="web1:9100",method="get",route="get/200"}`,
+		},
+		{
+			Name: "replace does not apply without a match",
+			Rules: []RelabelRule{{
+				SourceLabels: []string{"code"},
+				Regex:        "5..",
+				TargetLabel:  "error",
+				Replacement:  "true",
+			}},
+			Keep: true,
+			Want: `http_requests_total{code="200",instance="web1:9100",method="get"}`,
+		},
+		{
+			Name: "replace the metric name",
+			Rules: []RelabelRule{{
+				SourceLabels: []string{nameLabel},
+				Regex:        "http_(.*)",
+				TargetLabel:  nameLabel,
+			}},
+			Keep: true,
+			Want: `requests_total{code="200",instance="web1:9100",method="get"}`,
+		},
+		{
+			Name: "replace with empty value deletes the label",
+			Rules: []RelabelRule{{
+				SourceLabels: []string{"missing"},
+				TargetLabel:  "method",
+			}},
+			Keep: true,
+			Want: `http_requests_total{code="200",instance="web1:9100"}`,
+		},
+		{
+			Name: "keep on match",
+			Rules: []RelabelRule{{
+				Action:       ActionKeep,
+				SourceLabels: []string{"code"},
+				Regex:        "2..",
+			}},
+			Keep: true,
+			Want: `http_requests_total{code="200",instance="web1:9100",method="get"}`,
+		},
+		{
+			Name: "keep without match",
+			Rules: []RelabelRule{{
+				Action:       ActionKeep,
+				SourceLabels: []string{"code"},
+				Regex:        "5..",
+			}},
+			Keep: false,
+		},
+		{
+			Name: "drop on match",
+			Rules: []RelabelRule{{
+				Action:       ActionDrop,
+				SourceLabels: []string{nameLabel},
+				Regex:        "http_.*",
+			}},
+			Keep: false,
+		},
+		{
+			Name: "drop is anchored",
+			Rules: []RelabelRule{{
+				Action:       ActionDrop,
+				SourceLabels: []string{nameLabel},
+				Regex:        "http",
+			}},
+			Keep: true,
+			Want: `http_requests_total{code="200",instance="web1:9100",method="get"}`,
+		},
+		{
+			Name: "labeldrop",
+			Rules: []RelabelRule{{
+				Action: ActionLabelDrop,
+				Regex:  "code|method",
+			}},
+			Keep: true,
+			Want: `http_requests_total{instance="web1:9100"}`,
+		},
+		{
+			Name: "labelkeep",
+			Rules: []RelabelRule{{
+				Action: ActionLabelKeep,
+				Regex:  "instance|code",
+			}},
+			Keep: true,
+			Want: `http_requests_total{code="200",instance="web1:9100"}`,
+		},
+		{
+			Name: "rules apply in order",
+			Rules: []RelabelRule{
+				{SourceLabels: []string{"code"}, Regex: "(.).*", TargetLabel: "class", Replacement: "${1}xx"},
+				{Action: ActionLabelDrop, Regex: "code"},
+				{Action: ActionKeep, SourceLabels: []string{"class"}, Regex: "2xx"},
+			},
+			Keep: true,
+			Want: `http_requests_total{class="2xx",instance="web1:9100",method="get"}`,
+		},
+	}
+	for _, test := range tests {
+		rules, err := compileRules(test.Rules)
+		if err != nil {
+			t.Errorf("%s: %v", test.Name, err)
+			continue
+		}
+		s := Sample{
+			Name:   "http_requests_total",
+			Labels: map[string]string{"code": "200", "method": "get", "instance": "web1:9100"},
+			Value:  1,
+		}
+		if keep := relabel(&s, rules); keep != test.Keep {
+			t.Errorf("%s: expected keep of %v; got %v", test.Name, test.Keep, keep)
+			continue
+		}
+		if test.Keep {
+			if got := s.Key(); got != test.Want {
+				t.Errorf("%s: expected %s; got %s", test.Name, test.Want, got)
+			}
+		}
+	}
+}
+
+func TestCompileRules(t *testing.T) {
+	rules, err := compileRules([]RelabelRule{{TargetLabel: "x"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := RelabelRule{Separator: ";", Regex: "(.*)", TargetLabel: "x", Replacement: "$1", Action: ActionReplace}
+	if !reflect.DeepEqual(rules[0].RelabelRule, want) {
+		t.Errorf("expected %+v; got %+v", want, rules[0].RelabelRule)
+	}
+
+	invalid := [][]RelabelRule{
+		{{Action: "hashmod"}},
+		{{Action: ActionReplace}},
+		{{Action: ActionKeep, Regex: "("}},
+	}
+	for _, rules := range invalid {
+		if _, err := compileRules(rules); err == nil {
+			t.Errorf("expected error for %+v", rules)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package psi
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+// Config is the configuration for the psi plugin.
+type Config struct {
+	// Cgroups to report the pressure of, as patterns relative to
+	// /sys/fs/cgroup (cgroup v2), e.g. "system.slice/*.service".
+	Cgroups []string
+}
+
+// Plugin watches the Pressure Stall Information (PSI) of a machine
+// and of selected cgroups. It requires Linux 4.20 or later; on other
+// systems it reports that PSI is not supported.
+//
+// Stall time rates are derived from the difference between two
+// snapshots, so the first snapshot reports zero rates.
+type Plugin struct {
+	cgroups  []string
+	last     map[string]uint64 // total stall times by metric name
+	lastTime time.Time
+}
+
+// NewPlugin initializes a new Plugin to watch the pressure stall
+// information.
+func NewPlugin(config *Config) (*Plugin, error) {
+	if config == nil {
+		return nil, errors.New("no configuration specified")
+	}
+	for _, pattern := range config.Cgroups {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, err
+		}
+	}
+	return &Plugin{cgroups: config.Cgroups}, nil
+}
+
+// Name of the plugin.
+func (p *Plugin) Name() string {
+	return "psi"
+}
+
+// Snapshot returns the current pressure on CPU, memory and I/O.
+func (p *Plugin) Snapshot() (interface{}, error) {
+	now := time.Now()
+	secs := now.Sub(p.lastTime).Seconds()
+	if p.last == nil {
+		secs = 0
+	}
+	totals := make(map[string]uint64)
+
+	host := p.resources("psi", plugins.ProcPath("pressure"), "", totals, secs)
+	if len(host) == 0 {
+		// Kernel without PSI, or PSI disabled via psi=0
+		return map[string]interface{}{"supported": false}, nil
+	}
+	data := map[string]interface{}{"supported": true}
+	for name, value := range host {
+		data[name] = value
+	}
+
+	if len(p.cgroups) > 0 {
+		cgroups := make(map[string]interface{})
+		cgroupDir := plugins.SysPath("fs", "cgroup")
+		for _, cgroup := range plugins.GlobDirs(cgroupDir, p.cgroups) {
+			prefix := "psi.cgroup." + cgroup
+			dir := filepath.Join(cgroupDir, cgroup)
+			if res := p.resources(prefix, dir, ".pressure", totals, secs); len(res) > 0 {
+				cgroups[cgroup] = res
+			}
+		}
+		data["cgroups"] = cgroups
+	}
+
+	p.last = totals
+	p.lastTime = now
+
+	// Return data
+	return data, nil
+}
+
+// resources reads the pressure files of all resources in dir, e.g.
+// dir/cpu or dir/cpu.pressure, and returns them keyed by resource.
+// Resources without a pressure file are skipped.
+func (p *Plugin) resources(prefix, dir, suffix string, totals map[string]uint64, secs float64) map[string]interface{} {
+	data := make(map[string]interface{})
+	for _, resource := range Resources {
+		pressure, err := ReadPressure(filepath.Join(dir, resource+suffix))
+		if err != nil {
+			continue
+		}
+		res := make(map[string]interface{})
+		for kind, stall := range map[string]*Stall{"some": pressure.Some, "full": pressure.Full} {
+			if stall == nil {
+				continue
+			}
+			name := prefix + "." + resource + "." + kind
+			totals[name] = stall.Total
+			var rate float64
+			if prev, found := p.last[name]; found {
+				rate = plugins.Rate(stall.Total, prev, secs)
+			}
+			values := map[string]float64{
+				"avg10":            stall.Avg10,
+				"avg60":            stall.Avg60,
+				"avg300":           stall.Avg300,
+				"total_us_per_sec": rate,
+			}
+			m := make(map[string]interface{})
+			for key, value := range values {
+				metrics.GetOrRegisterGaugeFloat64(name+"."+key, nil).Update(value)
+				m[key] = value
+			}
+			m["total_us"] = stall.Total
+			res[kind] = m
+		}
+		data[resource] = res
+	}
+	return data
+}
@@ -0,0 +1,100 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package psi
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Resources that the kernel reports pressure for.
+var Resources = []string{"cpu", "memory", "io"}
+
+// Stall is the share of time in which tasks were stalled on a resource.
+type Stall struct {
+	Avg10  float64 // percent over the last 10 seconds
+	Avg60  float64 // percent over the last 60 seconds
+	Avg300 float64 // percent over the last 300 seconds
+	Total  uint64  // total stall time in microseconds
+}
+
+// Pressure is the pressure on a resource. Some is the time in which at
+// least one task was stalled, Full is the time in which all non-idle
+// tasks were stalled. Full is nil for the CPU before Linux 5.13.
+type Pressure struct {
+	Some *Stall
+	Full *Stall
+}
+
+// ReadPressure reads a pressure file like /proc/pressure/cpu or the
+// cpu.pressure file of a cgroup.
+func ReadPressure(filename string) (*Pressure, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParsePressure(f)
+}
+
+// ParsePressure parses the contents of a pressure file.
+// See Documentation/accounting/psi.rst in the kernel sources.
+func ParsePressure(r io.Reader) (*Pressure, error) {
+	p := &Pressure{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		stall, err := parseStall(fields[1:])
+		if err != nil {
+			return nil, err
+		}
+		switch fields[0] {
+		case "some":
+			p.Some = stall
+		case "full":
+			p.Full = stall
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if p.Some == nil {
+		return nil, fmt.Errorf("no pressure information found")
+	}
+	return p, nil
+}
+
+// parseStall parses fields like "avg10=0.00 avg60=0.00 avg300=0.00 total=0".
+func parseStall(fields []string) (*Stall, error) {
+	s := &Stall{}
+	for _, field := range fields {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid pressure field %q", field)
+		}
+		var err error
+		switch kv[0] {
+		case "avg10":
+			s.Avg10, err = strconv.ParseFloat(kv[1], 64)
+		case "avg60":
+			s.Avg60, err = strconv.ParseFloat(kv[1], 64)
+		case "avg300":
+			s.Avg300, err = strconv.ParseFloat(kv[1], 64)
+		case "total":
+			s.Total, err = strconv.ParseUint(kv[1], 10, 64)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package psi
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadPressure(t *testing.T) {
+	tests := []struct {
+		Resource string
+		Some     Stall
+		Full     *Stall
+	}{
+		{
+			// No full line for the CPU before Linux 5.13
+			Resource: "cpu",
+			Some:     Stall{Avg10: 1.5, Avg60: 0.75, Avg300: 0.25, Total: 123456},
+		},
+		{
+			Resource: "memory",
+			Some:     Stall{Avg10: 0, Avg60: 0.1, Avg300: 0.05, Total: 5000},
+			Full:     &Stall{Avg10: 0, Avg60: 0.02, Avg300: 0.01, Total: 1000},
+		},
+		{
+			Resource: "io",
+			Some:     Stall{Avg10: 12.34, Avg60: 5.67, Avg300: 1.23, Total: 98765432},
+			Full:     &Stall{Avg10: 10, Avg60: 4, Avg300: 1, Total: 87654321},
+		},
+	}
+	for _, test := range tests {
+		p, err := ReadPressure(filepath.Join("testdata", "proc", "pressure", test.Resource))
+		if err != nil {
+			t.Errorf("%s: %v", test.Resource, err)
+			continue
+		}
+		if p.Some == nil || *p.Some != test.Some {
+			t.Errorf("%s: expected some %+v; got %+v", test.Resource, test.Some, p.Some)
+		}
+		switch {
+		case test.Full == nil && p.Full != nil:
+			t.Errorf("%s: expected no full; got %+v", test.Resource, p.Full)
+		case test.Full != nil && (p.Full == nil || *p.Full != *test.Full):
+			t.Errorf("%s: expected full %+v; got %+v", test.Resource, test.Full, p.Full)
+		}
+	}
+}
+
+func TestParsePressureInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"full avg10=0.00 avg60=0.00 avg300=0.00 total=0\n",
+		"some avg10=0.00 avg60 avg300=0.00 total=0\n",
+		"some avg10=x avg60=0.00 avg300=0.00 total=0\n",
+		"some avg10=0.00 avg60=0.00 avg300=0.00 total=-1\n",
+	}
+	for _, input := range tests {
+		if _, err := ParsePressure(strings.NewReader(input)); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package plugins
+
+// Delta returns the difference of two readings of a counter, or zero if
+// the counter has wrapped or was reset, e.g. because a service restarted.
+func Delta(cur, prev uint64) float64 {
+	if cur < prev {
+		return 0
+	}
+	return float64(cur - prev)
+}
+
+// DeltaInt is like Delta for counters of type int64.
+func DeltaInt(cur, prev int64) float64 {
+	if cur < prev {
+		return 0
+	}
+	return float64(cur - prev)
+}
+
+// PerSec returns v per second, or zero if no time has passed.
+func PerSec(v, secs float64) float64 {
+	if secs <= 0 {
+		return 0
+	}
+	return v / secs
+}
+
+// Rate returns the change per second of a counter between two readings
+// that are secs seconds apart. It returns zero if the counter has wrapped
+// or was reset.
+func Rate(cur, prev uint64, secs float64) float64 {
+	return PerSec(Delta(cur, prev), secs)
+}
+
+// RateInt is like Rate for counters of type int64.
+func RateInt(cur, prev int64, secs float64) float64 {
+	return PerSec(DeltaInt(cur, prev), secs)
+}
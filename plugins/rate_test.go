@@ -0,0 +1,29 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package plugins
+
+import "testing"
+
+func TestRate(t *testing.T) {
+	tests := []struct {
+		cur, prev uint64
+		secs      float64
+		want      float64
+	}{
+		{cur: 300, prev: 100, secs: 2, want: 100},
+		{cur: 100, prev: 100, secs: 2, want: 0},
+		{cur: 50, prev: 100, secs: 2, want: 0},   // reset
+		{cur: 300, prev: 100, secs: 0, want: 0},  // no time passed
+		{cur: 300, prev: 100, secs: -1, want: 0}, // clock went backwards
+	}
+	for _, tt := range tests {
+		if got := Rate(tt.cur, tt.prev, tt.secs); got != tt.want {
+			t.Errorf("Rate(%d, %d, %v): expected %v; got %v", tt.cur, tt.prev, tt.secs, tt.want, got)
+		}
+		if got := RateInt(int64(tt.cur), int64(tt.prev), tt.secs); got != tt.want {
+			t.Errorf("RateInt(%d, %d, %v): expected %v; got %v", tt.cur, tt.prev, tt.secs, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+const (
+	defaultAddress = "localhost:6379"
+	defaultTimeout = 10 * time.Second
+)
+
+// Config is the configuration for the Redis plugin.
+type Config struct {
+	// Address of the server, either host:port or the path of a unix
+	// socket (default: localhost:6379).
+	Address string
+
+	// Username and Password to authenticate with. Username is only
+	// supported with Redis 6 ACLs and may be empty.
+	Username string
+	Password string
+
+	// Timeout to connect and run INFO (default: 10s).
+	Timeout time.Duration
+}
+
+// Plugin that watches a Redis server.
+//
+// Rates and the hit ratio are derived from the difference between two
+// snapshots, so the first snapshot reports zero rates and the hit
+// ratio since the start of the server.
+type Plugin struct {
+	name     string
+	address  string
+	username string
+	password string
+	timeout  time.Duration
+	last     Info
+	lastTime time.Time
+}
+
+// NewPlugin initializes a new watcher for a Redis server.
+// Pass a name to differentiate between different servers.
+func NewPlugin(name string, config *Config) (*Plugin, error) {
+	if name == "" {
+		return nil, errors.New("no name specified")
+	}
+	if config == nil {
+		return nil, errors.New("no configuration specified")
+	}
+	plugin := &Plugin{
+		name:     name,
+		address:  config.Address,
+		username: config.Username,
+		password: config.Password,
+		timeout:  config.Timeout,
+	}
+	if plugin.address == "" {
+		plugin.address = defaultAddress
+	}
+	if plugin.timeout <= 0 {
+		plugin.timeout = defaultTimeout
+	}
+	return plugin, nil
+}
+
+// Name of the plugin. It is prefixed with "redis." so that instances
+// do not collide with other plugins of the same name.
+func (p *Plugin) Name() string {
+	return "redis." + p.name
+}
+
+// Snapshot returns the memory usage, clients, throughput, replication
+// and persistence status of the server. If the server is unreachable,
+// the health is reported as critical along with the error.
+func (p *Plugin) Snapshot() (interface{}, error) {
+	info, err := GetInfo(p.address, p.username, p.password, p.timeout)
+	if err != nil {
+		return map[string]interface{}{
+			"health": plugins.HealthCritical,
+			"error":  err.Error(),
+		}, nil
+	}
+	now := time.Now()
+	secs := now.Sub(p.lastTime).Seconds()
+	prev := p.last
+	if prev == nil || info.Int("uptime_in_seconds") < prev.Int("uptime_in_seconds") ||
+		info.Int("keyspace_hits") < prev.Int("keyspace_hits") ||
+		info.Int("keyspace_misses") < prev.Int("keyspace_misses") {
+		// First snapshot, or the server has been restarted or its
+		// statistics have been reset with CONFIG RESETSTAT
+		prev, secs = info, 0
+	}
+	p.last = info
+	p.lastTime = now
+
+	prefix := fmt.Sprintf("redis.%s.", p.name)
+	data := make(map[string]interface{})
+	gauge := func(key string, value int64) {
+		metrics.GetOrRegisterGauge(prefix+key, nil).Update(value)
+		data[key] = value
+	}
+	gaugeFloat64 := func(key string, value float64) {
+		metrics.GetOrRegisterGaugeFloat64(prefix+key, nil).Update(value)
+		data[key] = value
+	}
+
+	// Memory
+	gauge("used_memory", info.Int("used_memory"))
+	gauge("used_memory_rss", info.Int("used_memory_rss"))
+	gauge("maxmemory", info.Int("maxmemory"))
+	if max := info.Int("maxmemory"); max > 0 {
+		gaugeFloat64("memory_percent", float64(info.Int("used_memory"))/float64(max)*100.0)
+	}
+	gaugeFloat64("mem_fragmentation_ratio", info.Float("mem_fragmentation_ratio"))
+
+	// Clients and throughput
+	gauge("connected_clients", info.Int("connected_clients"))
+	gauge("blocked_clients", info.Int("blocked_clients"))
+	gauge("ops_per_sec", info.Int("instantaneous_ops_per_sec"))
+	gaugeFloat64("commands_per_sec", plugins.RateInt(info.Int("total_commands_processed"), prev.Int("total_commands_processed"), secs))
+	gaugeFloat64("connections_per_sec", plugins.RateInt(info.Int("total_connections_received"), prev.Int("total_connections_received"), secs))
+	gauge("rejected_connections", info.Int("rejected_connections"))
+
+	// Keyspace
+	hits := info.Int("keyspace_hits") - prev.Int("keyspace_hits")
+	misses := info.Int("keyspace_misses") - prev.Int("keyspace_misses")
+	if secs == 0 {
+		hits, misses = info.Int("keyspace_hits"), info.Int("keyspace_misses")
+	}
+	var hitRatio float64
+	if hits+misses > 0 {
+		hitRatio = float64(hits) / float64(hits+misses)
+	}
+	gaugeFloat64("keyspace_hit_ratio", hitRatio)
+	gaugeFloat64("keyspace_hits_per_sec", plugins.RateInt(info.Int("keyspace_hits"), prev.Int("keyspace_hits"), secs))
+	gaugeFloat64("keyspace_misses_per_sec", plugins.RateInt(info.Int("keyspace_misses"), prev.Int("keyspace_misses"), secs))
+	gauge("evicted_keys", info.Int("evicted_keys"))
+	gaugeFloat64("evicted_keys_per_sec", plugins.RateInt(info.Int("evicted_keys"), prev.Int("evicted_keys"), secs))
+	gaugeFloat64("expired_keys_per_sec", plugins.RateInt(info.Int("expired_keys"), prev.Int("expired_keys"), secs))
+	var keys int64
+	for field, value := range info {
+		if strings.HasPrefix(field, "db") {
+			n, _ := strconv.ParseInt(ParseValues(value)["keys"], 10, 64)
+			keys += n
+		}
+	}
+	gauge("keys", keys)
+
+	health := plugins.HealthOK
+
+	// Replication
+	data["role"] = info["role"]
+	gauge("connected_replicas", info.Int("connected_slaves"))
+	if info["role"] == "slave" {
+		linkUp := info["master_link_status"] == "up"
+		data["master_link_up"] = linkUp
+		if !linkUp {
+			health = plugins.HealthCritical
+		}
+		gauge("master_last_io_seconds_ago", info.Int("master_last_io_seconds_ago"))
+	} else {
+		// Largest lag of the replicas, in seconds and in bytes of the
+		// replication stream not yet acknowledged by the replica
+		var lag, offsetLag int64
+		replicas := make(map[string]interface{})
+		masterOffset := info.Int("master_repl_offset")
+		for i := int64(0); i < info.Int("connected_slaves"); i++ {
+			replica := ParseValues(info[fmt.Sprintf("slave%d", i)])
+			n, _ := strconv.ParseInt(replica["lag"], 10, 64)
+			if n > lag {
+				lag = n
+			}
+			offset, _ := strconv.ParseInt(replica["offset"], 10, 64)
+			var m int64
+			if offset < masterOffset {
+				m = masterOffset - offset
+			}
+			if m > offsetLag {
+				offsetLag = m
+			}
+			replicas[replica["ip"]+":"+replica["port"]] = map[string]interface{}{
+				"state":      replica["state"],
+				"lag":        n,
+				"offset_lag": m,
+			}
+		}
+		gauge("replication_lag", lag)
+		gauge("replication_offset_lag", offsetLag)
+		data["replicas"] = replicas
+	}
+
+	// Persistence
+	data["loading"] = info["loading"] == "1"
+	data["rdb_last_bgsave_status"] = info["rdb_last_bgsave_status"]
+	gauge("rdb_changes_since_last_save", info.Int("rdb_changes_since_last_save"))
+	gauge("rdb_last_save_time", info.Int("rdb_last_save_time"))
+	data["aof_enabled"] = info["aof_enabled"] == "1"
+	if info["aof_enabled"] == "1" {
+		data["aof_last_write_status"] = info["aof_last_write_status"]
+	}
+	persistenceFailed := info["rdb_last_bgsave_status"] == "err" ||
+		(info["aof_enabled"] == "1" && info["aof_last_write_status"] == "err")
+	if persistenceFailed && health == plugins.HealthOK {
+		health = plugins.HealthWarning
+	}
+
+	data["health"] = health
+	data["uptime"] = info.Int("uptime_in_seconds")
+	data["version"] = info["redis_version"]
+
+	// Return data
+	return data, nil
+}
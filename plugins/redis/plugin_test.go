@@ -0,0 +1,157 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package redis
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+func TestSnapshotMaster(t *testing.T) {
+	s := newServer(t, "", masterInfo)
+	defer s.Close()
+
+	p, err := NewPlugin("master", &Config{Address: s.Addr(), Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := data.(map[string]interface{})
+	tests := map[string]interface{}{
+		"health":                 plugins.HealthOK,
+		"role":                   "master",
+		"keys":                   int64(7),
+		"memory_percent":         25.0,
+		"keyspace_hit_ratio":     0.9,
+		"commands_per_sec":       0.0, // first snapshot
+		"connected_replicas":     int64(2),
+		"replication_lag":        int64(3),
+		"replication_offset_lag": int64(2500),
+	}
+	for key, want := range tests {
+		if got := m[key]; got != want {
+			t.Errorf("expected %s of %v; got %v", key, want, got)
+		}
+	}
+	replicas := m["replicas"].(map[string]interface{})
+	replica := replicas["10.0.0.3:6379"].(map[string]interface{})
+	if got := replica["offset_lag"]; got != int64(2500) {
+		t.Errorf("expected offset lag of 2500; got %v", got)
+	}
+
+	// Rates and the hit ratio are computed since the last snapshot
+	info := strings.Replace(masterInfo, "total_commands_processed:500", "total_commands_processed:600", 1)
+	info = strings.Replace(info, "keyspace_hits:90", "keyspace_hits:100", 1)
+	info = strings.Replace(info, "keyspace_misses:10", "keyspace_misses:20", 1)
+	s.SetInfo(info)
+	time.Sleep(10 * time.Millisecond)
+	data, err = p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = data.(map[string]interface{})
+	if got := m["commands_per_sec"].(float64); got <= 0 {
+		t.Errorf("expected commands_per_sec > 0; got %v", got)
+	}
+	if got := m["keyspace_hit_ratio"]; got != 0.5 {
+		t.Errorf("expected keyspace_hit_ratio of 0.5; got %v", got)
+	}
+}
+
+func TestSnapshotResetStat(t *testing.T) {
+	s := newServer(t, "", masterInfo)
+	defer s.Close()
+
+	p, err := NewPlugin("resetstat", &Config{Address: s.Addr(), Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+
+	// CONFIG RESETSTAT resets the counters, but not the uptime
+	info := strings.Replace(masterInfo, "keyspace_hits:90", "keyspace_hits:3", 1)
+	info = strings.Replace(info, "keyspace_misses:10", "keyspace_misses:1", 1)
+	s.SetInfo(info)
+	time.Sleep(10 * time.Millisecond)
+	data, err := p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := data.(map[string]interface{})
+	tests := map[string]interface{}{
+		"keyspace_hit_ratio":      0.75, // since the reset
+		"keyspace_hits_per_sec":   0.0,
+		"keyspace_misses_per_sec": 0.0,
+	}
+	for key, want := range tests {
+		if got := m[key]; got != want {
+			t.Errorf("expected %s of %v; got %v", key, want, got)
+		}
+	}
+}
+
+func TestSnapshotReplica(t *testing.T) {
+	info := strings.Replace(masterInfo, `role:master
+connected_slaves:2
+slave0:ip=10.0.0.2,port=6379,state=online,offset=4000,lag=0
+slave1:ip=10.0.0.3,port=6379,state=online,offset=2500,lag=3
+`, `role:slave
+master_host:10.0.0.1
+master_port:6379
+master_link_status:down
+master_last_io_seconds_ago:42
+connected_slaves:0
+`, 1)
+	s := newServer(t, "", info)
+	defer s.Close()
+
+	p, err := NewPlugin("replica", &Config{Address: s.Addr(), Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := data.(map[string]interface{})
+	if got := m["health"]; got != plugins.HealthCritical {
+		t.Errorf("expected critical health with link down; got %v", got)
+	}
+	if got := m["master_link_up"]; got != false {
+		t.Errorf("expected master_link_up of false; got %v", got)
+	}
+	if got := m["master_last_io_seconds_ago"]; got != int64(42) {
+		t.Errorf("expected master_last_io_seconds_ago of 42; got %v", got)
+	}
+	if _, found := m["replication_offset_lag"]; found {
+		t.Error("expected no replication_offset_lag on a replica")
+	}
+}
+
+func TestSnapshotDown(t *testing.T) {
+	p, err := NewPlugin("down", &Config{Address: "127.0.0.1:1", Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := data.(map[string]interface{})
+	if got := m["health"]; got != plugins.HealthCritical {
+		t.Errorf("expected critical health; got %v", got)
+	}
+	if _, found := m["error"]; !found {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package redis
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Info is the parsed output of the INFO command, keyed by field name,
+// e.g. "used_memory". Fields with multiple values, like the keyspace
+// ("db0:keys=1,expires=0") or the replicas ("slave0:ip=...,lag=0"),
+// are kept as is and can be split with ParseValues.
+type Info map[string]string
+
+// Int returns the value of a field as an integer, or 0.
+func (info Info) Int(name string) int64 {
+	v, _ := strconv.ParseInt(info[name], 10, 64)
+	return v
+}
+
+// Float returns the value of a field as a float, or 0.
+func (info Info) Float(name string) float64 {
+	v, _ := strconv.ParseFloat(info[name], 64)
+	return v
+}
+
+// GetInfo connects to the server at address, authenticates if a
+// password is given, and returns the output of INFO. The address is
+// either host:port or the path of a unix socket.
+func GetInfo(address, username, password string, timeout time.Duration) (Info, error) {
+	network := "tcp"
+	if strings.HasPrefix(address, "/") {
+		network = "unix"
+	}
+	conn, err := net.DialTimeout(network, address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	r := bufio.NewReader(conn)
+	if password != "" {
+		args := []string{"AUTH", password}
+		if username != "" {
+			args = []string{"AUTH", username, password}
+		}
+		if _, err := call(conn, r, args...); err != nil {
+			return nil, err
+		}
+	}
+	reply, err := call(conn, r, "INFO")
+	if err != nil {
+		return nil, err
+	}
+	return ParseInfo(bytes.NewReader(reply))
+}
+
+// call sends a command to the server and returns the reply. Only
+// status, error and bulk string replies are supported.
+func call(w io.Writer, r *bufio.Reader, args ...string) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&buf, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return nil, err
+	}
+
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return []byte(line[1:]), nil
+	case '-':
+		return nil, errors.New(line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid reply %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2) // including trailing \r\n
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	}
+	return nil, fmt.Errorf("unexpected reply %q", line)
+}
+
+// ParseInfo parses the output of the INFO command.
+func ParseInfo(r io.Reader) (Info, error) {
+	info := make(Info)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.Index(line, ":")
+		if i < 0 {
+			continue
+		}
+		info[line[:i]] = line[i+1:]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// ParseValues splits a field with multiple values like
+// "keys=1,expires=0,avg_ttl=0".
+func ParseValues(s string) map[string]string {
+	values := make(map[string]string)
+	for _, kv := range strings.Split(s, ",") {
+		if i := strings.Index(kv, "="); i >= 0 {
+			values[kv[:i]] = kv[i+1:]
+		}
+	}
+	return values
+}
@@ -0,0 +1,192 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package redis
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// server is a stand-in for a Redis server that speaks enough RESP to
+// answer AUTH and INFO.
+type server struct {
+	l        net.Listener
+	password string
+
+	mu   sync.Mutex
+	info string
+}
+
+func newServer(t *testing.T, password, info string) *server {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &server{l: l, password: password, info: info}
+	go s.serve()
+	return s
+}
+
+func (s *server) Addr() string { return s.l.Addr().String() }
+
+func (s *server) Close() { s.l.Close() }
+
+func (s *server) SetInfo(info string) {
+	s.mu.Lock()
+	s.info = info
+	s.mu.Unlock()
+}
+
+func (s *server) serve() {
+	for {
+		conn, err := s.l.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *server) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	authenticated := s.password == ""
+	for {
+		// Read an array of bulk strings
+		var n int
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		if _, err := fmt.Sscanf(line, "*%d\r\n", &n); err != nil {
+			return
+		}
+		var args []string
+		for i := 0; i < n; i++ {
+			if _, err := r.ReadString('\n'); err != nil { // $<len>
+				return
+			}
+			arg, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			args = append(args, strings.TrimRight(arg, "\r\n"))
+		}
+
+		switch {
+		case len(args) > 0 && args[0] == "AUTH":
+			if args[len(args)-1] == s.password {
+				authenticated = true
+				fmt.Fprint(conn, "+OK\r\n")
+			} else {
+				fmt.Fprint(conn, "-WRONGPASS invalid username-password pair\r\n")
+			}
+		case !authenticated:
+			fmt.Fprint(conn, "-NOAUTH Authentication required.\r\n")
+		case len(args) > 0 && args[0] == "INFO":
+			s.mu.Lock()
+			info := strings.Replace(s.info, "\n", "\r\n", -1)
+			s.mu.Unlock()
+			fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(info), info)
+		default:
+			fmt.Fprint(conn, "-ERR unknown command\r\n")
+		}
+	}
+}
+
+const masterInfo = `# Server
+redis_version:6.2.6
+uptime_in_seconds:1000
+
+# Clients
+connected_clients:3
+blocked_clients:0
+
+# Memory
+used_memory:1000
+used_memory_rss:2000
+maxmemory:4000
+mem_fragmentation_ratio:2.00
+
+# Stats
+total_connections_received:10
+total_commands_processed:500
+instantaneous_ops_per_sec:5
+rejected_connections:0
+expired_keys:0
+evicted_keys:0
+keyspace_hits:90
+keyspace_misses:10
+
+# Replication
+role:master
+connected_slaves:2
+slave0:ip=10.0.0.2,port=6379,state=online,offset=4000,lag=0
+slave1:ip=10.0.0.3,port=6379,state=online,offset=2500,lag=3
+master_repl_offset:5000
+
+# Persistence
+loading:0
+rdb_changes_since_last_save:7
+rdb_last_save_time:1445000000
+rdb_last_bgsave_status:ok
+aof_enabled:0
+
+# Keyspace
+db0:keys=5,expires=0,avg_ttl=0
+db1:keys=2,expires=1,avg_ttl=100
+`
+
+func TestGetInfo(t *testing.T) {
+	s := newServer(t, "secret", masterInfo)
+	defer s.Close()
+
+	info, err := GetInfo(s.Addr(), "", "secret", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info["redis_version"]; got != "6.2.6" {
+		t.Errorf("expected version 6.2.6; got %q", got)
+	}
+	if got := info.Int("used_memory"); got != 1000 {
+		t.Errorf("expected used_memory 1000; got %d", got)
+	}
+	if got := info.Float("mem_fragmentation_ratio"); got != 2.0 {
+		t.Errorf("expected mem_fragmentation_ratio 2.0; got %v", got)
+	}
+	if _, found := info["# Server"]; found {
+		t.Error("expected section headers to be skipped")
+	}
+
+	// With username, as for Redis 6 ACLs
+	if _, err := GetInfo(s.Addr(), "default", "secret", time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := GetInfo(s.Addr(), "", "wrong", time.Second); err == nil || !strings.HasPrefix(err.Error(), "WRONGPASS") {
+		t.Errorf("expected WRONGPASS error; got %v", err)
+	}
+	if _, err := GetInfo(s.Addr(), "", "", time.Second); err == nil || !strings.HasPrefix(err.Error(), "NOAUTH") {
+		t.Errorf("expected NOAUTH error; got %v", err)
+	}
+}
+
+func TestParseValues(t *testing.T) {
+	values := ParseValues("ip=10.0.0.2,port=6379,state=online,offset=4000,lag=0")
+	want := map[string]string{"ip": "10.0.0.2", "port": "6379", "state": "online", "offset": "4000", "lag": "0"}
+	if len(values) != len(want) {
+		t.Fatalf("expected %v; got %v", want, values)
+	}
+	for k, v := range want {
+		if values[k] != v {
+			t.Errorf("expected %s=%s; got %s", k, v, values[k])
+		}
+	}
+}
@@ -12,12 +12,19 @@ var (
 )
 
 // Register a plugin. Use this function before starting a Metrononme server.
+// Register panics if a plugin with the same name is already registered, as
+// the snapshots of both plugins would be reported under the same key.
 func Register(plugin Plugin) {
 	if plugin == nil {
 		panic("metronome: Register plugin is nil")
 	}
 	pluginsMu.Lock()
 	defer pluginsMu.Unlock()
+	for _, p := range plugins {
+		if p.Name() == plugin.Name() {
+			panic("metronome: Register called twice for plugin " + plugin.Name())
+		}
+	}
 	if plugins == nil {
 		plugins = make([]Plugin, 0)
 	}
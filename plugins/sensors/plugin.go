@@ -0,0 +1,76 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package sensors
+
+import (
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// Plugin watches the hardware sensors of a machine: temperatures, fan
+// speeds and voltages, and the state of batteries and AC adapters.
+type Plugin struct {
+}
+
+// NewPlugin initializes a new Plugin to watch the hardware sensors.
+func NewPlugin() (*Plugin, error) {
+	return &Plugin{}, nil
+}
+
+// Name of the plugin.
+func (p *Plugin) Name() string {
+	return "sensors"
+}
+
+// Snapshot returns the current sensor readings. Readings are keyed by
+// chip and label, e.g. "coretemp/Core 0".
+func (p *Plugin) Snapshot() (interface{}, error) {
+	hwmon, err := GetHwmon()
+	if err != nil {
+		return nil, err
+	}
+	thermal, err := GetThermalZones()
+	if err != nil {
+		return nil, err
+	}
+	supplies, err := GetPowerSupplies()
+	if err != nil {
+		return nil, err
+	}
+
+	kinds := map[string]map[string]interface{}{
+		Temperature: make(map[string]interface{}),
+		Fan:         make(map[string]interface{}),
+		Voltage:     make(map[string]interface{}),
+	}
+	for _, r := range append(hwmon, thermal...) {
+		key := r.Chip + "/" + r.Label
+		metrics.GetOrRegisterGaugeFloat64("sensors."+r.Kind+"."+key, nil).Update(r.Value)
+		kinds[r.Kind][key] = r.Value
+	}
+
+	powerSupplies := make(map[string]interface{})
+	for _, ps := range supplies {
+		data := map[string]interface{}{"type": ps.Type}
+		if ps.Status != "" {
+			data["status"] = ps.Status
+		}
+		if ps.Online != nil {
+			data["online"] = *ps.Online
+		}
+		if ps.Capacity != nil {
+			metrics.GetOrRegisterGauge("sensors.power_supply."+ps.Name+".capacity", nil).Update(*ps.Capacity)
+			data["capacity"] = *ps.Capacity
+		}
+		powerSupplies[ps.Name] = data
+	}
+
+	// Return data
+	return map[string]interface{}{
+		"temperatures":   kinds[Temperature],
+		"fans":           kinds[Fan],
+		"voltages":       kinds[Voltage],
+		"power_supplies": powerSupplies,
+	}, nil
+}
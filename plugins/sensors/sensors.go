@@ -0,0 +1,178 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package sensors
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+var (
+	// Matches e.g. temp1_input, fan2_input, in0_input
+	hwmonInputPattern = regexp.MustCompile(`^(temp|fan|in)(\d+)_input$`)
+)
+
+// Kinds of sensor readings.
+const (
+	Temperature = "temperature" // in degrees Celsius
+	Fan         = "fan"         // in RPM
+	Voltage     = "voltage"     // in Volts
+)
+
+// Reading is the value of a sensor.
+type Reading struct {
+	Chip  string // e.g. "coretemp" or "thermal_zone0"
+	Label string // e.g. "Core 0", or the sensor name like "temp1"
+	Kind  string // Temperature, Fan or Voltage
+	Value float64
+}
+
+// PowerSupply is the state of a battery or AC adapter.
+type PowerSupply struct {
+	Name     string
+	Type     string // e.g. "Battery", "Mains" or "USB"
+	Online   *bool  // for AC adapters
+	Status   string // e.g. "Charging" or "Discharging" for batteries
+	Capacity *int64 // percent, for batteries
+}
+
+// GetHwmon returns the readings of all sensors in /sys/class/hwmon.
+func GetHwmon() ([]Reading, error) {
+	dirs, err := filepath.Glob(plugins.SysPath("class", "hwmon", "*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(dirs)
+
+	var readings []Reading
+	chips := make(map[string]bool)
+	for _, hwmon := range dirs {
+		// Older kernels put the sensor files into the device directory.
+		dir := hwmon
+		if _, err := os.Stat(filepath.Join(dir, "name")); err != nil {
+			dir = filepath.Join(hwmon, "device")
+		}
+		chip := readString(filepath.Join(dir, "name"))
+		if chip == "" {
+			continue
+		}
+		if chips[chip] {
+			// Disambiguate multiple chips of the same kind, e.g. NVMe disks
+			chip = chip + "-" + filepath.Base(hwmon)
+		}
+		chips[chip] = true
+
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, fi := range files {
+			m := hwmonInputPattern.FindStringSubmatch(fi.Name())
+			if m == nil {
+				continue
+			}
+			raw, err := readInt(filepath.Join(dir, fi.Name()))
+			if err != nil {
+				// E.g. ENODATA for disconnected sensors
+				continue
+			}
+			sensor := m[1] + m[2]
+			label := readString(filepath.Join(dir, sensor+"_label"))
+			if label == "" {
+				label = sensor
+			}
+			r := Reading{Chip: chip, Label: label}
+			switch m[1] {
+			case "temp":
+				r.Kind, r.Value = Temperature, float64(raw)/1000
+			case "fan":
+				r.Kind, r.Value = Fan, float64(raw)
+			case "in":
+				r.Kind, r.Value = Voltage, float64(raw)/1000
+			}
+			readings = append(readings, r)
+		}
+	}
+	return readings, nil
+}
+
+// GetThermalZones returns the temperatures of /sys/class/thermal.
+func GetThermalZones() ([]Reading, error) {
+	dirs, err := filepath.Glob(plugins.SysPath("class", "thermal", "thermal_zone*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(dirs)
+
+	var readings []Reading
+	for _, dir := range dirs {
+		temp, err := readInt(filepath.Join(dir, "temp"))
+		if err != nil {
+			continue
+		}
+		label := readString(filepath.Join(dir, "type"))
+		if label == "" {
+			label = "temp"
+		}
+		readings = append(readings, Reading{
+			Chip:  filepath.Base(dir),
+			Label: label,
+			Kind:  Temperature,
+			Value: float64(temp) / 1000,
+		})
+	}
+	return readings, nil
+}
+
+// GetPowerSupplies returns the batteries and AC adapters of
+// /sys/class/power_supply.
+func GetPowerSupplies() ([]PowerSupply, error) {
+	dirs, err := filepath.Glob(plugins.SysPath("class", "power_supply", "*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(dirs)
+
+	var supplies []PowerSupply
+	for _, dir := range dirs {
+		ps := PowerSupply{
+			Name:   filepath.Base(dir),
+			Type:   readString(filepath.Join(dir, "type")),
+			Status: readString(filepath.Join(dir, "status")),
+		}
+		if online, err := readInt(filepath.Join(dir, "online")); err == nil {
+			b := online != 0
+			ps.Online = &b
+		}
+		if capacity, err := readInt(filepath.Join(dir, "capacity")); err == nil {
+			ps.Capacity = &capacity
+		}
+		supplies = append(supplies, ps)
+	}
+	return supplies, nil
+}
+
+func readString(filename string) string {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func readInt(filename string) (int64, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
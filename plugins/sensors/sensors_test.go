@@ -0,0 +1,112 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package sensors
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+func TestGetHwmon(t *testing.T) {
+	defer func(root string) { plugins.SysRoot = root }(plugins.SysRoot)
+	plugins.SysRoot = filepath.Join("testdata", "sys")
+
+	readings, err := GetHwmon()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Reading{
+		{Chip: "coretemp", Label: "Package id 0", Kind: Temperature, Value: 45},
+		{Chip: "coretemp", Label: "Core 0", Kind: Temperature, Value: 43.5},
+		// fan2 has no reading and in0 has no label
+		{Chip: "nct6775", Label: "CPU Fan", Kind: Fan, Value: 1200},
+		{Chip: "nct6775", Label: "in0", Kind: Voltage, Value: 1.128},
+		// Sensor files in the device directory, as with older kernels
+		{Chip: "nvme", Label: "Composite", Kind: Temperature, Value: 38.85},
+		// A second chip of the same name
+		{Chip: "nvme-hwmon3", Label: "Composite", Kind: Temperature, Value: 40},
+	}
+	if !reflect.DeepEqual(readings, want) {
+		t.Errorf("expected\n%+v\ngot\n%+v", want, readings)
+	}
+}
+
+func TestGetThermalZones(t *testing.T) {
+	defer func(root string) { plugins.SysRoot = root }(plugins.SysRoot)
+	plugins.SysRoot = filepath.Join("testdata", "sys")
+
+	readings, err := GetThermalZones()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Reading{
+		{Chip: "thermal_zone0", Label: "x86_pkg_temp", Kind: Temperature, Value: 47},
+		{Chip: "thermal_zone1", Label: "temp", Kind: Temperature, Value: 30},
+	}
+	if !reflect.DeepEqual(readings, want) {
+		t.Errorf("expected\n%+v\ngot\n%+v", want, readings)
+	}
+}
+
+func TestGetPowerSupplies(t *testing.T) {
+	defer func(root string) { plugins.SysRoot = root }(plugins.SysRoot)
+	plugins.SysRoot = filepath.Join("testdata", "sys")
+
+	supplies, err := GetPowerSupplies()
+	if err != nil {
+		t.Fatal(err)
+	}
+	online := true
+	capacity := int64(87)
+	want := []PowerSupply{
+		{Name: "AC", Type: "Mains", Online: &online},
+		{Name: "BAT0", Type: "Battery", Status: "Discharging", Capacity: &capacity},
+	}
+	if !reflect.DeepEqual(supplies, want) {
+		t.Errorf("expected\n%+v\ngot\n%+v", want, supplies)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	defer func(root string) { plugins.SysRoot = root }(plugins.SysRoot)
+	plugins.SysRoot = filepath.Join("testdata", "sys")
+
+	p, err := NewPlugin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := snapshot.(map[string]interface{})
+
+	temperatures := data["temperatures"].(map[string]interface{})
+	if got := temperatures["coretemp/Core 0"]; got != 43.5 {
+		t.Errorf("expected %v; got %v", 43.5, got)
+	}
+	if got := temperatures["thermal_zone0/x86_pkg_temp"]; got != 47.0 {
+		t.Errorf("expected %v; got %v", 47.0, got)
+	}
+	if got := len(temperatures); got != 6 {
+		t.Errorf("expected %d temperatures; got %d", 6, got)
+	}
+	if got := data["fans"].(map[string]interface{})["nct6775/CPU Fan"]; got != 1200.0 {
+		t.Errorf("expected %v; got %v", 1200.0, got)
+	}
+	if got := data["voltages"].(map[string]interface{})["nct6775/in0"]; got != 1.128 {
+		t.Errorf("expected %v; got %v", 1.128, got)
+	}
+	battery := data["power_supplies"].(map[string]interface{})["BAT0"].(map[string]interface{})
+	if got := battery["capacity"]; got != int64(87) {
+		t.Errorf("expected %v; got %v", 87, got)
+	}
+	if got := battery["status"]; got != "Discharging" {
+		t.Errorf("expected %q; got %v", "Discharging", got)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package sockets
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+// rates are the protocol counters reported per second, by protocol
+// and counter name, with the key used in the snapshot.
+var rates = []struct {
+	proto, name, key string
+}{
+	{"Tcp", "ActiveOpens", "tcp_active_opens_per_sec"},
+	{"Tcp", "PassiveOpens", "tcp_passive_opens_per_sec"},
+	{"Tcp", "AttemptFails", "tcp_attempt_fails_per_sec"},
+	{"Tcp", "EstabResets", "tcp_estab_resets_per_sec"},
+	{"Tcp", "InSegs", "tcp_in_segs_per_sec"},
+	{"Tcp", "OutSegs", "tcp_out_segs_per_sec"},
+	{"Tcp", "RetransSegs", "tcp_retrans_segs_per_sec"},
+	{"Tcp", "InErrs", "tcp_in_errors_per_sec"},
+	{"TcpExt", "ListenOverflows", "tcp_listen_overflows_per_sec"},
+	{"TcpExt", "ListenDrops", "tcp_listen_drops_per_sec"},
+	{"Udp", "InDatagrams", "udp_in_datagrams_per_sec"},
+	{"Udp", "OutDatagrams", "udp_out_datagrams_per_sec"},
+	{"Udp", "InErrors", "udp_in_errors_per_sec"},
+	{"Udp", "NoPorts", "udp_no_ports_per_sec"},
+	{"Udp", "RcvbufErrors", "udp_rcvbuf_errors_per_sec"},
+	{"Udp", "SndbufErrors", "udp_sndbuf_errors_per_sec"},
+}
+
+// Config is the configuration for the sockets plugin.
+type Config struct {
+	// Ports are local ports to report the number of TCP connections of,
+	// e.g. 9200 for Elasticsearch.
+	Ports []int
+}
+
+// Plugin watches the TCP and UDP sockets of a machine.
+//
+// Rates are derived from the difference between two snapshots, so the
+// first snapshot reports zero rates.
+type Plugin struct {
+	ports    []int
+	last     map[string]map[string]int64
+	lastTime time.Time
+}
+
+// NewPlugin initializes a new Plugin to watch the sockets.
+func NewPlugin(config *Config) (*Plugin, error) {
+	if config == nil {
+		return nil, errors.New("no configuration specified")
+	}
+	for _, port := range config.Ports {
+		if port <= 0 || port > 65535 {
+			return nil, fmt.Errorf("invalid port %d", port)
+		}
+	}
+	return &Plugin{ports: config.Ports}, nil
+}
+
+// Name of the plugin.
+func (p *Plugin) Name() string {
+	return "sockets"
+}
+
+// Snapshot returns the number of sockets by state and the protocol
+// counters since the last snapshot.
+func (p *Plugin) Snapshot() (interface{}, error) {
+	var tcp, udp []Socket
+	for _, proto := range []string{"tcp", "tcp6"} {
+		sockets, err := GetSockets(proto)
+		if err != nil {
+			return nil, err
+		}
+		tcp = append(tcp, sockets...)
+	}
+	for _, proto := range []string{"udp", "udp6"} {
+		sockets, err := GetSockets(proto)
+		if err != nil {
+			return nil, err
+		}
+		udp = append(udp, sockets...)
+	}
+	counters, err := GetCounters()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	secs := now.Sub(p.lastTime).Seconds()
+	prev := p.last
+	if prev == nil {
+		prev, secs = counters, 0
+	}
+	p.last = counters
+	p.lastTime = now
+
+	// Connections by state, and by local port for the watched ports
+	states := make(map[string]int64)
+	for _, state := range tcpStates {
+		states[state] = 0
+	}
+	ports := make(map[int]map[string]int64)
+	for _, port := range p.ports {
+		ports[port] = map[string]int64{"established": 0, "total": 0}
+	}
+	for _, s := range tcp {
+		states[s.State]++
+		if counts, found := ports[s.LocalPort]; found && s.State != "listen" {
+			counts["total"]++
+			if s.State == "established" {
+				counts["established"]++
+			}
+		}
+	}
+
+	data := make(map[string]interface{})
+	tcpStateData := make(map[string]interface{})
+	for state, n := range states {
+		metrics.GetOrRegisterGauge("sockets.tcp."+state, nil).Update(n)
+		tcpStateData[state] = n
+	}
+	data["tcp_states"] = tcpStateData
+	data["tcp_total"] = len(tcp)
+	data["udp_total"] = len(udp)
+
+	if len(p.ports) > 0 {
+		portData := make(map[string]interface{})
+		for port, counts := range ports {
+			name := strconv.Itoa(port)
+			metrics.GetOrRegisterGauge("sockets.ports."+name+".established", nil).Update(counts["established"])
+			metrics.GetOrRegisterGauge("sockets.ports."+name+".total", nil).Update(counts["total"])
+			portData[name] = counts
+		}
+		data["ports"] = portData
+	}
+
+	// Rates
+	for _, r := range rates {
+		v := plugins.RateInt(counters[r.proto][r.name], prev[r.proto][r.name], secs)
+		metrics.GetOrRegisterGaugeFloat64("sockets."+r.key, nil).Update(v)
+		data[r.key] = v
+	}
+	var retransPercent float64
+	out := counters["Tcp"]["OutSegs"] - prev["Tcp"]["OutSegs"]
+	retrans := counters["Tcp"]["RetransSegs"] - prev["Tcp"]["RetransSegs"]
+	if out > 0 && retrans >= 0 {
+		retransPercent = float64(retrans) / float64(out) * 100.0
+	}
+	metrics.GetOrRegisterGaugeFloat64("sockets.tcp_retrans_percent", nil).Update(retransPercent)
+	data["tcp_retrans_percent"] = retransPercent
+
+	// Return data
+	return data, nil
+}
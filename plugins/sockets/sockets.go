@@ -0,0 +1,151 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package sockets
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+// TCP states as used in /proc/net/tcp, see include/net/tcp_states.h.
+var tcpStates = map[uint64]string{
+	0x01: "established",
+	0x02: "syn_sent",
+	0x03: "syn_recv",
+	0x04: "fin_wait1",
+	0x05: "fin_wait2",
+	0x06: "time_wait",
+	0x07: "close",
+	0x08: "close_wait",
+	0x09: "last_ack",
+	0x0A: "listen",
+	0x0B: "closing",
+	0x0C: "new_syn_recv",
+}
+
+// Socket is an entry of /proc/net/{tcp,tcp6,udp,udp6}.
+type Socket struct {
+	LocalPort  int
+	RemotePort int
+	State      string // e.g. "established"; only meaningful for TCP
+}
+
+// GetSockets returns the sockets of a protocol ("tcp", "tcp6", "udp"
+// or "udp6") from /proc/self/net, or from /proc/1/net, i.e. of the
+// network namespace of init, if a host root is configured.
+// A missing file, e.g. if IPv6 is disabled, yields no sockets.
+func GetSockets(proto string) ([]Socket, error) {
+	f, err := os.Open(plugins.NamespacePath("net", proto))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseSockets(f)
+}
+
+// ParseSockets parses the contents of /proc/net/tcp and similar files.
+func ParseSockets(r io.Reader) ([]Socket, error) {
+	var sockets []Socket
+	scanner := bufio.NewScanner(r)
+	scanner.Scan() // skip header
+	for scanner.Scan() {
+		// sl local_address rem_address st ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		local, err := parsePort(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		remote, err := parsePort(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		st, err := strconv.ParseUint(fields[3], 16, 8)
+		if err != nil {
+			return nil, err
+		}
+		state, found := tcpStates[st]
+		if !found {
+			state = "unknown"
+		}
+		sockets = append(sockets, Socket{LocalPort: local, RemotePort: remote, State: state})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return sockets, nil
+}
+
+// parsePort returns the port of an address like "0100007F:0CEA".
+func parsePort(addr string) (int, error) {
+	i := strings.LastIndex(addr, ":")
+	if i < 0 {
+		return 0, fmt.Errorf("invalid address %q", addr)
+	}
+	port, err := strconv.ParseUint(addr[i+1:], 16, 16)
+	return int(port), err
+}
+
+// GetCounters returns the protocol counters from net/snmp and
+// net/netstat below /proc/self (or /proc/1 if a host root is configured),
+// keyed by protocol (e.g. "Tcp", "TcpExt") and name (e.g. "RetransSegs").
+func GetCounters() (map[string]map[string]int64, error) {
+	counters := make(map[string]map[string]int64)
+	for _, name := range []string{"snmp", "netstat"} {
+		f, err := os.Open(plugins.NamespacePath("net", name))
+		if err != nil {
+			return nil, err
+		}
+		err = ParseCounters(f, counters)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return counters, nil
+}
+
+// ParseCounters parses the contents of /proc/net/snmp or
+// /proc/net/netstat into counters. Both files consist of pairs of
+// lines, the first with the names and the second with the values:
+//
+//	Tcp: RtoAlgorithm RtoMin ...
+//	Tcp: 1 200 ...
+func ParseCounters(r io.Reader, counters map[string]map[string]int64) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		names := strings.Fields(scanner.Text())
+		if !scanner.Scan() {
+			break
+		}
+		values := strings.Fields(scanner.Text())
+		if len(names) == 0 || len(names) != len(values) || names[0] != values[0] {
+			return fmt.Errorf("invalid counters format near %q", strings.Join(names, " "))
+		}
+		proto := strings.TrimSuffix(names[0], ":")
+		if counters[proto] == nil {
+			counters[proto] = make(map[string]int64)
+		}
+		for i := 1; i < len(names); i++ {
+			v, err := strconv.ParseInt(values[i], 10, 64)
+			if err != nil {
+				return err
+			}
+			counters[proto][names[i]] = v
+		}
+	}
+	return scanner.Err()
+}
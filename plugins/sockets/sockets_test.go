@@ -0,0 +1,64 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package sockets
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+func TestGetSockets(t *testing.T) {
+	defer func(root string) { plugins.ProcRoot = root }(plugins.ProcRoot)
+	plugins.ProcRoot = "testdata/proc"
+
+	sockets, err := GetSockets("tcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Socket{
+		{LocalPort: 22, RemotePort: 0, State: "listen"},
+		{LocalPort: 8080, RemotePort: 54321, State: "established"},
+		{LocalPort: 54321, RemotePort: 8080, State: "time_wait"},
+	}
+	if !reflect.DeepEqual(sockets, want) {
+		t.Errorf("expected\n%+v\ngot\n%+v", want, sockets)
+	}
+
+	// E.g. IPv6 disabled
+	sockets, err = GetSockets("tcp6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sockets) != 0 {
+		t.Errorf("expected no sockets; got %+v", sockets)
+	}
+}
+
+func TestGetCounters(t *testing.T) {
+	defer func(root string) { plugins.ProcRoot = root }(plugins.ProcRoot)
+	plugins.ProcRoot = "testdata/proc"
+
+	counters, err := GetCounters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		proto, name string
+		want        int64
+	}{
+		{"Ip", "InReceives", 1000},
+		{"Tcp", "MaxConn", -1},
+		{"Tcp", "RetransSegs", 7},
+		{"Tcp", "CurrEstab", 4},
+		{"TcpExt", "ListenOverflows", 9},
+	}
+	for _, tt := range tests {
+		if got := counters[tt.proto][tt.name]; got != tt.want {
+			t.Errorf("%s.%s: expected %d; got %d", tt.proto, tt.name, tt.want, got)
+		}
+	}
+}
@@ -6,17 +6,52 @@
 
 package swap
 
-import "syscall"
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
 
-func GetSwap() (*Swap, error) {
-	sysinfo := &syscall.Sysinfo_t{}
+	"github.com/olivere/metronome/plugins"
+)
 
-	if err := syscall.Sysinfo(sysinfo); err != nil {
+func GetSwap() (*Swap, error) {
+	f, err := os.Open(plugins.ProcPath("meminfo"))
+	if err != nil {
 		return nil, err
 	}
-	mem := &Swap{
-		Total: int64(sysinfo.Totalswap),
-		Free:  int64(sysinfo.Freeswap),
+	defer f.Close()
+	return ParseMemInfo(f)
+}
+
+// ParseMemInfo parses the swap usage from the contents of /proc/meminfo.
+func ParseMemInfo(r io.Reader) (*Swap, error) {
+	mem := &Swap{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		values := strings.Split(scanner.Text(), ":")
+		if len(values) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(values[0])
+		if key != "SwapTotal" && key != "SwapFree" {
+			continue
+		}
+		value := strings.TrimSuffix(strings.TrimSpace(values[1]), " kB")
+		t, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case "SwapTotal":
+			mem.Total = t * 1024
+		case "SwapFree":
+			mem.Free = t * 1024
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
 	}
 	mem.Used = mem.Total - mem.Free
 	if mem.Total != 0 {
@@ -0,0 +1,54 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+// +build linux
+
+package swap
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+func TestGetSwap(t *testing.T) {
+	defer func(root string) { plugins.ProcRoot = root }(plugins.ProcRoot)
+	plugins.ProcRoot = filepath.Join("testdata", "proc")
+
+	swap, err := GetSwap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Swap{
+		Total:       2097152 * 1024,
+		Free:        1572864 * 1024,
+		Used:        524288 * 1024,
+		UsedPercent: 25,
+	}
+	if *swap != want {
+		t.Errorf("expected %+v; got %+v", want, *swap)
+	}
+}
+
+func TestParseMemInfoWithoutSwap(t *testing.T) {
+	input := `MemTotal:        1000000 kB
+SwapTotal:             0 kB
+SwapFree:              0 kB
+`
+	swap, err := ParseMemInfo(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *swap != (Swap{}) {
+		t.Errorf("expected no swap; got %+v", *swap)
+	}
+}
+
+func TestParseMemInfoInvalid(t *testing.T) {
+	if _, err := ParseMemInfo(strings.NewReader("SwapTotal: lots kB\n")); err == nil {
+		t.Error("expected error for invalid value")
+	}
+}
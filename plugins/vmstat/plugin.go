@@ -0,0 +1,97 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package vmstat
+
+import (
+	"time"
+
+	metrics "github.com/rcrowley/go-metrics"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+// Plugin watches the paging and swapping activity of a machine.
+//
+// Rates are derived from the difference between two snapshots, so the
+// first snapshot reports zero rates.
+type Plugin struct {
+	last     *VMStat
+	lastTime time.Time
+
+	pageIn      metrics.GaugeFloat64
+	pageOut     metrics.GaugeFloat64
+	swapIn      metrics.GaugeFloat64
+	swapOut     metrics.GaugeFloat64
+	majorFaults metrics.GaugeFloat64
+	minorFaults metrics.GaugeFloat64
+	oomKills    metrics.Gauge
+}
+
+// NewPlugin initializes a new Plugin to watch the paging activity.
+func NewPlugin() (*Plugin, error) {
+	p := &Plugin{}
+	p.pageIn = metrics.NewGaugeFloat64()
+	metrics.Register("vmstat.page_in_kb_per_sec", p.pageIn)
+	p.pageOut = metrics.NewGaugeFloat64()
+	metrics.Register("vmstat.page_out_kb_per_sec", p.pageOut)
+	p.swapIn = metrics.NewGaugeFloat64()
+	metrics.Register("vmstat.swap_in_pages_per_sec", p.swapIn)
+	p.swapOut = metrics.NewGaugeFloat64()
+	metrics.Register("vmstat.swap_out_pages_per_sec", p.swapOut)
+	p.majorFaults = metrics.NewGaugeFloat64()
+	metrics.Register("vmstat.major_faults_per_sec", p.majorFaults)
+	p.minorFaults = metrics.NewGaugeFloat64()
+	metrics.Register("vmstat.minor_faults_per_sec", p.minorFaults)
+	p.oomKills = metrics.NewGauge()
+	metrics.Register("vmstat.oom_kills", p.oomKills)
+	return p, nil
+}
+
+// Name of the plugin.
+func (p *Plugin) Name() string {
+	return "vmstat"
+}
+
+// Snapshot returns the paging activity since the last snapshot.
+func (p *Plugin) Snapshot() (interface{}, error) {
+	vm, err := GetVMStat()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	prev, secs := p.last, now.Sub(p.lastTime).Seconds()
+	if prev == nil {
+		prev, secs = vm, 0
+	}
+	p.last = vm
+	p.lastTime = now
+
+	// Update metrics
+	p.pageIn.Update(plugins.Rate(vm.PageIn, prev.PageIn, secs))
+	p.pageOut.Update(plugins.Rate(vm.PageOut, prev.PageOut, secs))
+	p.swapIn.Update(plugins.Rate(vm.SwapIn, prev.SwapIn, secs))
+	p.swapOut.Update(plugins.Rate(vm.SwapOut, prev.SwapOut, secs))
+	p.majorFaults.Update(plugins.Rate(vm.MajorFaults, prev.MajorFaults, secs))
+	p.minorFaults.Update(plugins.Rate(vm.MinorFaults(), prev.MinorFaults(), secs))
+	p.oomKills.Update(int64(vm.OOMKills))
+
+	var newOOMKills uint64
+	if vm.OOMKills > prev.OOMKills {
+		newOOMKills = vm.OOMKills - prev.OOMKills
+	}
+
+	// Return data
+	return map[string]interface{}{
+		"page_in_kb_per_sec":     p.pageIn.Value(),
+		"page_out_kb_per_sec":    p.pageOut.Value(),
+		"swap_in_pages_per_sec":  p.swapIn.Value(),
+		"swap_out_pages_per_sec": p.swapOut.Value(),
+		"major_faults_per_sec":   p.majorFaults.Value(),
+		"minor_faults_per_sec":   p.minorFaults.Value(),
+		"oom_kills":              p.oomKills.Value(),
+		"oom_kills_since_last":   newOOMKills,
+	}, nil
+}
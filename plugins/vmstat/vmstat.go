@@ -0,0 +1,25 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+package vmstat
+
+// VMStat is the paging and swapping activity since boot.
+type VMStat struct {
+	PageIn      uint64 // kilobytes paged in from disk
+	PageOut     uint64 // kilobytes paged out to disk
+	SwapIn      uint64 // pages swapped in
+	SwapOut     uint64 // pages swapped out
+	PageFaults  uint64 // all page faults, major and minor
+	MajorFaults uint64 // page faults that required disk I/O
+	OOMKills    uint64 // processes killed by the OOM killer
+}
+
+// MinorFaults returns the number of page faults that did not require
+// disk I/O.
+func (s *VMStat) MinorFaults() uint64 {
+	if s.PageFaults < s.MajorFaults {
+		return 0
+	}
+	return s.PageFaults - s.MajorFaults
+}
@@ -0,0 +1,14 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+// +build darwin
+
+package vmstat
+
+import "errors"
+
+// GetVMStat is not supported on Darwin.
+func GetVMStat() (*VMStat, error) {
+	return nil, errors.New("vmstat: not supported on darwin")
+}
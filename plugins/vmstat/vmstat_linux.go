@@ -0,0 +1,62 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+// +build linux
+
+package vmstat
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+// GetVMStat returns the paging activity from /proc/vmstat.
+func GetVMStat() (*VMStat, error) {
+	f, err := os.Open(plugins.ProcPath("vmstat"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseVMStat(f)
+}
+
+// ParseVMStat parses the contents of /proc/vmstat. Counters that the
+// kernel does not report (e.g. oom_kill before 4.13) are left at zero.
+func ParseVMStat(r io.Reader) (*VMStat, error) {
+	s := &VMStat{}
+	fields := map[string]*uint64{
+		"pgpgin":     &s.PageIn,
+		"pgpgout":    &s.PageOut,
+		"pswpin":     &s.SwapIn,
+		"pswpout":    &s.SwapOut,
+		"pgfault":    &s.PageFaults,
+		"pgmajfault": &s.MajorFaults,
+		"oom_kill":   &s.OOMKills,
+	}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		kv := strings.Fields(scanner.Text())
+		if len(kv) != 2 {
+			continue
+		}
+		dst, found := fields[kv[0]]
+		if !found {
+			continue
+		}
+		v, err := strconv.ParseUint(kv[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		*dst = v
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2012-2015 Oliver Eilhard. All rights reserved.
+// Use of this source code is governed by a MIT-license.
+// See http://olivere.mit-license.org/license.txt for details.
+
+// +build linux
+
+package vmstat
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/olivere/metronome/plugins"
+)
+
+func TestParseVMStat(t *testing.T) {
+	defer func(root string) { plugins.ProcRoot = root }(plugins.ProcRoot)
+	plugins.ProcRoot = "testdata/proc"
+
+	s, err := GetVMStat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := VMStat{
+		PageIn:      1048576,
+		PageOut:     2097152,
+		SwapIn:      100,
+		SwapOut:     250,
+		PageFaults:  5000000,
+		MajorFaults: 1200,
+		OOMKills:    3,
+	}
+	if *s != want {
+		t.Errorf("expected %+v; got %+v", want, *s)
+	}
+	if got := s.MinorFaults(); got != 4998800 {
+		t.Errorf("expected 4998800 minor faults; got %d", got)
+	}
+}
+
+func TestParseVMStatOldKernel(t *testing.T) {
+	// No oom_kill before Linux 4.13
+	s, err := ParseVMStat(strings.NewReader("pgpgin 10\npgpgout 20\npgfault 5\npgmajfault 7\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.OOMKills != 0 {
+		t.Errorf("expected no OOM kills; got %d", s.OOMKills)
+	}
+	if got := s.MinorFaults(); got != 0 {
+		t.Errorf("expected 0 minor faults if major faults exceed all faults; got %d", got)
+	}
+
+	if _, err := ParseVMStat(strings.NewReader("pgpgin x\n")); err == nil {
+		t.Error("expected error for invalid value")
+	}
+}